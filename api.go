@@ -0,0 +1,86 @@
+package golisp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// EvalString parses src and evaluates it in e. It returns the value of the
+// last form.
+func (e *Env) EvalString(ctx context.Context, src string) (*Node, error) {
+	node, err := NewParser(strings.NewReader(src)).Parse()
+	if err != nil {
+		return nil, err
+	}
+	ret, err := e.EvalContext(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		ret = Nil()
+	}
+	return ret, nil
+}
+
+// Call calls the function named name with args. Arguments that are not
+// *Node are converted with Marshal. name may be qualified with a package
+// like pkg:name.
+func (e *Env) Call(ctx context.Context, name string, args ...interface{}) (*Node, error) {
+	if _, ok := e.Lookup(name); !ok {
+		return nil, fmt.Errorf("undefined function: %v", name)
+	}
+	if ft, ok := e.op(intern(name)); ok && ft.ft == FtSpecial {
+		return nil, fmt.Errorf("can not call special form: %v", name)
+	}
+	nodes := make([]*Node, len(args))
+	for i, arg := range args {
+		n, ok := arg.(*Node)
+		if !ok {
+			var err error
+			n, err = Marshal(arg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		nodes[i] = &Node{
+			t:   NodeQuote,
+			car: n,
+		}
+	}
+	return e.EvalContext(ctx, List(Cons(Symbol(name), List(nodes...))))
+}
+
+// CallInto calls the function named name with args like Call, and stores
+// the result in the value pointed to by v with Unmarshal.
+func (e *Env) CallInto(ctx context.Context, v interface{}, name string, args ...interface{}) error {
+	ret, err := e.Call(ctx, name, args...)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(ret, v)
+}
+
+// Lookup returns the value of the variable named name as seen from e, or
+// the function named name if there is no such variable.
+func (e *Env) Lookup(name string) (*Node, bool) {
+	if name == "" {
+		return nil, false
+	}
+	sym := intern(name)
+	for env := e; env != nil; env = env.env {
+		if v, ok := env.getVar(sym); ok {
+			return v, true
+		}
+		if v, ok := env.getFn(sym); ok {
+			return v, true
+		}
+	}
+	if fn, _, _, err := lookupFunction(e, sym); err == nil && fn != nil {
+		return fn, true
+	}
+	if _, ok := e.op(sym); ok {
+		return Symbol(name), true
+	}
+	return nil, false
+}
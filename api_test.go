@@ -0,0 +1,113 @@
+package golisp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEvalString(t *testing.T) {
+	env := NewEnv(nil)
+	ctx := context.Background()
+	ret, err := env.EvalString(ctx, `(defun add (a b) (+ a b)) (setq x 40) (add x 2)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, ok := ret.AsInt(); !ok || i != 42 {
+		t.Fatalf("want 42 but got %v", ret)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := env.EvalString(canceled, `(add 1 2)`); err == nil {
+		t.Fatal("want error for canceled context")
+	}
+}
+
+func TestCall(t *testing.T) {
+	env := NewEnv(nil)
+	ctx := context.Background()
+	_, err := env.EvalString(ctx, `
+(defun total (items) (apply '+ items))
+(defpackage :shop (:export :price))
+(in-package :shop)
+(defun price (item) (get item 'price))
+(in-package :user)
+(setf (get 'apple 'price) 120)
+(setq double (lambda (x) (* x 2)))
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := env.Call(ctx, "total", []int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int
+	if err := Unmarshal(ret, &total); err != nil || total != 6 {
+		t.Fatalf("want 6 but got %v (%v)", ret, err)
+	}
+
+	ret, err = env.Call(ctx, "shop:price", Symbol("apple"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, _ := ret.AsInt(); i != 120 {
+		t.Fatalf("want 120 but got %v", ret)
+	}
+
+	ret, err = env.Call(ctx, "double", 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, _ := ret.AsInt(); i != 42 {
+		t.Fatalf("want 42 but got %v", ret)
+	}
+
+	ret, err = env.Call(ctx, "concatenate", Symbol("string"), "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := ret.AsString(); s != "ab" {
+		t.Fatalf("want ab but got %v", ret)
+	}
+
+	var doubled float64
+	if err := env.CallInto(ctx, &doubled, "double", 1.25); err != nil || doubled != 2.5 {
+		t.Fatalf("want 2.5 but got %v (%v)", doubled, err)
+	}
+	var tags []string
+	if err := env.CallInto(ctx, &tags, "list", "a", "b"); err != nil || len(tags) != 2 || tags[1] != "b" {
+		t.Fatalf("want [a b] but got %v (%v)", tags, err)
+	}
+	if err := env.CallInto(ctx, &tags, "total", []int{1}); err == nil {
+		t.Fatal("want error for unmarshaling an int into a slice")
+	}
+
+	if _, err := env.Call(ctx, "nothing"); err == nil {
+		t.Fatal("want error for undefined function")
+	}
+	if _, err := env.Call(ctx, "if", 1, 2); err == nil {
+		t.Fatal("want error for special form")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	env := NewEnv(nil)
+	_, err := env.EvalString(context.Background(), `(setq x 1) (defun f () x)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := env.Lookup("x"); !ok || v.String() != "1" {
+		t.Errorf("want 1 but got %v", v)
+	}
+	if v, ok := env.Lookup("f"); !ok || v.Type() != NodeEnv {
+		t.Errorf("want function but got %v", v)
+	}
+	if _, ok := env.Lookup("car"); !ok {
+		t.Error("want builtin car")
+	}
+	if _, ok := env.Lookup("y"); ok {
+		t.Error("want no y")
+	}
+}
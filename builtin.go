@@ -0,0 +1,152 @@
+package golisp
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mattn/golisp/gopkg"
+)
+
+// DefineBuiltin registers fn as a function named name in the interpreter
+// of e. fn receives the list of evaluated arguments. Other interpreters are
+// not affected.
+func (e *Env) DefineBuiltin(name string, fn Fn) {
+	e.setOp(intern(name), makeFn(FtBuiltin, fn))
+}
+
+// DefineSpecial registers fn as a special form named name in the
+// interpreter of e. fn receives the arguments unevaluated.
+func (e *Env) DefineSpecial(name string, fn Fn) {
+	e.setOp(intern(name), makeFn(FtSpecial, fn))
+}
+
+// Undefine removes the builtin or special form named name from the
+// interpreter of e.
+func (e *Env) Undefine(name string) {
+	root := e.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	delete(root.ops, intern(name))
+}
+
+// Define binds name to v. A Go func becomes a builtin whose arguments and
+// results are converted with reflection; a trailing error result is
+// returned as an evaluation error, and a leading *Env parameter receives
+// the calling environment. Other values are bound as global variables.
+func (e *Env) Define(name string, v interface{}) error {
+	switch fn := v.(type) {
+	case Fn:
+		e.DefineBuiltin(name, fn)
+		return nil
+	case func(*Env, *Node) (*Node, error):
+		e.DefineBuiltin(name, fn)
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Func {
+		if rv.IsNil() {
+			return fmt.Errorf("nil function for %v", name)
+		}
+		e.DefineBuiltin(name, func(env *Env, node *Node) (*Node, error) {
+			return goCall(env, rv, node)
+		})
+		return nil
+	}
+	e.root().putVar(intern(name), valueToNode(rv))
+	return nil
+}
+
+// ImportPackage registers the members of the Go package path for go:import
+// in the interpreter of e. Values are converted with reflect.ValueOf unless
+// they already are a reflect.Value. Members of an earlier import of path
+// are kept unless replaced. Packages registered in gopkg remain available,
+// and are shadowed by a package of the same path.
+func (e *Env) ImportPackage(path string, members map[string]interface{}) error {
+	pkg := make(map[string]reflect.Value, len(members))
+	for name, v := range members {
+		rv, ok := v.(reflect.Value)
+		if !ok {
+			rv = reflect.ValueOf(v)
+		}
+		if !rv.IsValid() {
+			return fmt.Errorf("nil member %v of package %v", name, path)
+		}
+		pkg[name] = rv
+	}
+	root := e.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if root.goPkgs == nil {
+		root.goPkgs = make(map[string]map[string]reflect.Value)
+	}
+	// go:import hands out the map itself, so it is copied, not updated.
+	for name, rv := range root.goPkgs[path] {
+		if _, ok := pkg[name]; !ok {
+			pkg[name] = rv
+		}
+	}
+	root.goPkgs[path] = pkg
+	return nil
+}
+
+// ImportTypes registers the types of the Go package path for type
+// expressions like path.Name in the interpreter of e. Each value is a
+// reflect.Type, or a nil pointer to the type such as (*T)(nil).
+func (e *Env) ImportTypes(path string, types map[string]interface{}) error {
+	typs := make(map[string]reflect.Type, len(types))
+	for name, v := range types {
+		switch t := v.(type) {
+		case reflect.Type:
+			typs[name] = t
+		case nil:
+			return fmt.Errorf("nil type %v of package %v", name, path)
+		default:
+			typ := reflect.TypeOf(v)
+			if typ.Kind() != reflect.Ptr {
+				return fmt.Errorf("invalid type %v of package %v: %v", name, path, typ)
+			}
+			typs[name] = typ.Elem()
+		}
+	}
+	root := e.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if root.goTypes == nil {
+		root.goTypes = make(map[string]map[string]reflect.Type)
+	}
+	for name, typ := range root.goTypes[path] {
+		if _, ok := typs[name]; !ok {
+			typs[name] = typ
+		}
+	}
+	root.goTypes[path] = typs
+	return nil
+}
+
+// goPackage returns the members of the Go package path registered in e or
+// in gopkg.
+func (e *Env) goPackage(path string) (map[string]reflect.Value, bool) {
+	root := e.root()
+	root.mu.RLock()
+	pkg, ok := root.goPkgs[path]
+	root.mu.RUnlock()
+	if ok {
+		return pkg, true
+	}
+	pkg, ok = gopkg.Packages[path]
+	return pkg, ok
+}
+
+// goPackageType returns the type name of the Go package path registered in
+// e or in gopkg. known reports whether any type of path is registered.
+func (e *Env) goPackageType(path, name string) (typ reflect.Type, known bool) {
+	root := e.root()
+	root.mu.RLock()
+	typs, ok := root.goTypes[path]
+	root.mu.RUnlock()
+	if typ, found := typs[name]; found {
+		return typ, true
+	}
+	typs, known = gopkg.PackageTypes[path]
+	return typs[name], known || ok
+}
@@ -0,0 +1,166 @@
+package golisp
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func evalString(t *testing.T, env *Env, src string) (*Node, error) {
+	t.Helper()
+	node, err := NewParser(strings.NewReader(src)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return env.Eval(node)
+}
+
+func TestDefine(t *testing.T) {
+	env1 := NewEnv(nil)
+	env2 := NewEnv(nil)
+
+	env1.DefineBuiltin("twice", func(env *Env, node *Node) (*Node, error) {
+		return doPlus(env, &Node{
+			t:   NodeCell,
+			car: node.car,
+			cdr: node,
+		})
+	})
+	env1.DefineSpecial("quote-it", func(env *Env, node *Node) (*Node, error) {
+		return node.car, nil
+	})
+	if err := env1.Define("join", func(sep string, s ...string) string {
+		return strings.Join(s, sep)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := env1.Define("fail", func(s string) (int, error) {
+		return 0, errors.New(s)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := env1.Define("answer", 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := env1.Define("maxuint", func() uint64 { return math.MaxUint64 }); err != nil {
+		t.Fatal(err)
+	}
+	env1.Undefine("getenv")
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: `(twice 21)`, want: "42"},
+		{input: `(quote-it (undefined form))`, want: "(undefined form)"},
+		{input: `(join "," "a" "b" "c")`, want: `"a,b,c"`},
+		{input: `(+ answer 1)`, want: "43"},
+	}
+	for _, test := range tests {
+		got, err := evalString(t, env1, test.input)
+		if err != nil {
+			t.Fatalf("%v: %v", test.input, err)
+		}
+		if got.String() != test.want {
+			t.Errorf("want %q for %q but got %q", test.want, test.input, got.String())
+		}
+	}
+
+	if got, err := evalString(t, env1, `(maxuint)`); err != nil {
+		t.Fatal(err)
+	} else if v, _ := got.AsGoValue(); v != uint64(math.MaxUint64) {
+		t.Errorf("want %v but got %v", uint64(math.MaxUint64), got)
+	}
+	if _, err := evalString(t, env1, `(fail "boom")`); err == nil || err.Error() != "boom" {
+		t.Errorf("want error %q but got %v", "boom", err)
+	}
+	if _, err := evalString(t, env1, `(getenv "HOME")`); err == nil {
+		t.Error("want error for undefined getenv")
+	}
+	if _, err := evalString(t, env2, `(twice 21)`); err == nil {
+		t.Error("want error for twice in other interpreter")
+	}
+	if _, err := evalString(t, env2, `(getenv "HOME")`); err != nil {
+		t.Error(err)
+	}
+}
+
+type item struct {
+	Name  string
+	Price float64
+}
+
+func TestImportPackage(t *testing.T) {
+	env1 := NewEnv(nil)
+	env2 := NewEnv(nil)
+
+	if err := env1.ImportPackage("myorg/pricing", map[string]interface{}{
+		"Discount": func(price float64) float64 { return price * 0.9 },
+		"Currency": "EUR",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := env1.ImportPackage("myorg/pricing", map[string]interface{}{
+		"Total": reflect.ValueOf(func(items []*item) float64 {
+			total := 0.0
+			for _, it := range items {
+				total += it.Price
+			}
+			return total
+		}),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := env1.ImportTypes("myorg/pricing", map[string]interface{}{
+		"Item":  (*item)(nil),
+		"Price": reflect.TypeOf(float64(0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: `(.Discount (go:import 'myorg/pricing) 100)`, want: "(90)"},
+		{input: `(car (. (go:import 'myorg/pricing) Currency))`, want: `"EUR"`},
+		{input: `(.Total (go:import 'myorg/pricing) (list (go:new myorg/pricing.Item :Price 1.5) (go:new myorg/pricing.Item :Price 2)))`, want: "(3.5)"},
+		{input: `(go:convert myorg/pricing.Price 2)`, want: "2"},
+		{input: `(go:len (go:make-slice net/http.Cookie 2))`, want: "2"},
+	}
+	for _, test := range tests {
+		got, err := evalString(t, env1, test.input)
+		if err != nil {
+			t.Fatalf("%v: %v", test.input, err)
+		}
+		if got.String() != test.want {
+			t.Errorf("want %q for %q but got %q", test.want, test.input, got.String())
+		}
+	}
+
+	for _, src := range []string{
+		`(go:import 'myorg/pricing)`,
+		`(go:new myorg/pricing.Item)`,
+	} {
+		if _, err := evalString(t, env2, src); err == nil {
+			t.Errorf("%s: want error in other interpreter", src)
+		}
+	}
+	if _, err := evalString(t, env1, `(go:new myorg/pricing.Missing)`); err == nil {
+		t.Error("want error for unknown type")
+	}
+
+	env1.SetSandbox(Sandbox{})
+	if _, err := evalString(t, env1, `(go:import 'myorg/pricing)`); !errors.Is(err, ErrForbidden) {
+		t.Errorf("want ErrForbidden but got %v", err)
+	}
+
+	if err := env1.ImportPackage("p", map[string]interface{}{"x": nil}); err == nil {
+		t.Error("want error for nil member")
+	}
+	if err := env1.ImportTypes("p", map[string]interface{}{"T": item{}}); err == nil {
+		t.Error("want error for non-pointer type")
+	}
+}
@@ -0,0 +1,133 @@
+package golisp
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// isFunction reports whether node is a lambda, or a symbol naming a
+// function or builtin.
+func isFunction(env *Env, node *Node) bool {
+	switch node.t {
+	case NodeLambda, NodeEnv:
+		return true
+	case NodeIdent:
+		name := node.v.(*symbol)
+		if ft, ok := env.op(name); ok {
+			return ft.ft == FtBuiltin
+		}
+		for e := env; e != nil; e = e.env {
+			if _, ok := e.getFn(name); ok {
+				return true
+			}
+			if v, ok := e.getVar(name); ok {
+				return v.t == NodeLambda
+			}
+		}
+		fn, _, macro, err := lookupFunction(env, name)
+		return err == nil && fn != nil && !macro
+	}
+	return false
+}
+
+// goCallErrors collects the errors of callbacks passed to a Go call while
+// it runs, so that the call returns them.
+type goCallErrors struct {
+	mu   sync.Mutex
+	err  error
+	done bool
+}
+
+// add records err if the call is still running.
+func (c *goCallErrors) add(err error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		return false
+	}
+	if c.err == nil {
+		c.err = err
+	}
+	return true
+}
+
+// finish ends the call and returns the first error of its callbacks.
+func (c *goCallErrors) finish() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done = true
+	return c.err
+}
+
+// makeFunc wraps the Lisp function fn as a Go func of type typ. Each call
+// evaluates fn in its own scope, so the func may be called from any
+// goroutine.
+func makeFunc(env *Env, fn *Node, typ reflect.Type) reflect.Value {
+	return makeCallback(env, fn, typ, nil)
+}
+
+// makeCallback is makeFunc for a func passed to a Go call collecting errors
+// in call. An error is returned through a trailing error result of typ.
+// Otherwise the func returns zero values, and the error is returned by the
+// Go call if it is still running, or reported to the handler set with
+// SetGoErrorHandler.
+func makeCallback(env *Env, fn *Node, typ reflect.Type, call *goCallErrors) reflect.Value {
+	return reflect.MakeFunc(typ, func(args []reflect.Value) []reflect.Value {
+		nodes := make([]*Node, len(args))
+		for i, arg := range args {
+			nodes[i] = &Node{
+				t:   NodeQuote,
+				car: valueToNode(arg),
+			}
+		}
+		ret, err := eval(NewEnv(env), Cons(fn, List(nodes...)))
+		if err == nil {
+			var rets []reflect.Value
+			rets, err = funcResults(ret, typ)
+			if err == nil {
+				return rets
+			}
+		}
+		n := typ.NumOut()
+		rets := make([]reflect.Value, n)
+		for i := 0; i < n; i++ {
+			rets[i] = reflect.Zero(typ.Out(i))
+		}
+		if n > 0 && typ.Out(n-1) == errorType {
+			rets[n-1] = reflect.ValueOf(&err).Elem()
+		} else if call == nil || !call.add(err) {
+			env.goError(err)
+		}
+		return rets
+	})
+}
+
+// funcResults converts the value returned by a Lisp function to the
+// results of typ. Several results are returned as a list.
+func funcResults(ret *Node, typ reflect.Type) ([]reflect.Value, error) {
+	if ret == nil {
+		ret = Nil()
+	}
+	n := typ.NumOut()
+	rets := make([]reflect.Value, n)
+	if n > 0 && typ.Out(n-1) == errorType {
+		rets[n-1] = reflect.Zero(errorType)
+		n--
+	}
+	vals := []*Node{ret}
+	if n > 1 {
+		vals = ret.ToSlice()
+		if len(vals) != n {
+			return nil, fmt.Errorf("want %d results but got %v", n, ret)
+		}
+	}
+	for i := 0; i < n; i++ {
+		rv, err := goArg(nil, vals[i], typ.Out(i))
+		if err != nil {
+			return nil, err
+		}
+		rets[i] = rv
+	}
+	return rets, nil
+}
@@ -0,0 +1,93 @@
+package golisp
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCallback(t *testing.T) {
+	env := NewEnv(nil)
+	env.Define("call-with", func(f func(int) (int, error), x int) (int, error) {
+		return f(x)
+	})
+	env.Define("call-must", func(f func(string) string, s string) string {
+		return f(s)
+	})
+	env.Define("parallel", func(f func(int) int, n int) int {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		sum := 0
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				v := f(i)
+				mu.Lock()
+				sum += v
+				mu.Unlock()
+			}(i)
+		}
+		wg.Wait()
+		return sum
+	})
+
+	ret, err := env.EvalString(context.Background(), `(defun twice (x) (* x 2)) (call-with 'twice 21)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := ret.AsInt(); v != 42 {
+		t.Fatalf("want 42 but got %v", ret)
+	}
+
+	ret, err = env.EvalString(context.Background(), `(parallel (lambda (i) (setq last i) (1+ i)) 50)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := ret.AsInt(); v != 1275 {
+		t.Fatalf("want 1275 but got %v", ret)
+	}
+
+	_, err = env.EvalString(context.Background(), `(call-with (lambda (x) (undefined-function)) 1)`)
+	if err == nil || !strings.Contains(err.Error(), "undefined-function") {
+		t.Fatalf("want error of undefined-function but got %v", err)
+	}
+	_, err = env.EvalString(context.Background(), `(call-with (lambda (x) "not int") 1)`)
+	if err == nil {
+		t.Fatal("want error for invalid result")
+	}
+	_, err = env.EvalString(context.Background(), `(call-must (lambda (s) (undefined-function)) "a")`)
+	if err == nil || !strings.Contains(err.Error(), "undefined-function") {
+		t.Fatalf("want error of undefined-function but got %v", err)
+	}
+	_, err = env.EvalString(context.Background(), `(parallel (lambda (i) (undefined-function)) 3)`)
+	if err == nil || !strings.Contains(err.Error(), "undefined-function") {
+		t.Fatalf("want error of undefined-function but got %v", err)
+	}
+}
+
+func TestCallbackLater(t *testing.T) {
+	env := NewEnv(nil)
+	errc := make(chan error, 1)
+	env.SetGoErrorHandler(func(err error) {
+		errc <- err
+	})
+	var saved func(int) int
+	env.Define("save", func(f func(int) int) {
+		saved = f
+	})
+	if _, err := env.EvalString(context.Background(), `(save (lambda (i) (undefined-function)))`); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan int)
+	go func() {
+		done <- saved(1)
+	}()
+	if v := <-done; v != 0 {
+		t.Fatalf("want 0 but got %v", v)
+	}
+	if err := <-errc; !strings.Contains(err.Error(), "undefined-function") {
+		t.Fatalf("want error of undefined-function but got %v", err)
+	}
+}
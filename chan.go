@@ -0,0 +1,219 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// chanOf returns the channel wrapped by node.
+func chanOf(node *Node, name string) (reflect.Value, error) {
+	if node == nil || node.t != NodeGoValue {
+		return reflect.Value{}, fmt.Errorf("invalid arguments for %v", name)
+	}
+	ch := node.v.(reflect.Value)
+	if ch.Kind() != reflect.Chan {
+		return reflect.Value{}, fmt.Errorf("invalid arguments for %v", name)
+	}
+	return ch, nil
+}
+
+// duration converts nanoseconds or a string like "1.5s" to a duration.
+func duration(node *Node) (time.Duration, error) {
+	switch node.t {
+	case NodeInt:
+		return time.Duration(node.v.(int64)), nil
+	case NodeDouble:
+		return time.Duration(node.v.(float64)), nil
+	case NodeString:
+		return time.ParseDuration(node.v.(string))
+	}
+	return 0, fmt.Errorf("invalid duration: %v", node)
+}
+
+type selectClause struct {
+	vars *Node
+	body *Node
+}
+
+// doGoSelect waits on several channel operations like select in Go.
+//
+//	(go:select
+//	  ((:recv ch v ok) body...)
+//	  ((:send ch value) body...)
+//	  ((:timeout "1s") body...)
+//	  (:default body...))
+func doGoSelect(env *Env, node *Node) (rret *Node, rerr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			rerr = errors.New(fmt.Sprint(err))
+		}
+	}()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(env.done())},
+	}
+	clauses := []selectClause{{}}
+	var def *Node
+	hasDefault := false
+	for curr := node; curr != nil && curr.car != nil; curr = curr.cdr {
+		c := curr.car
+		if c.t != NodeCell || c.car == nil {
+			return nil, errors.New("invalid arguments for go:select")
+		}
+		if name, ok := c.car.AsSymbol(); ok && name == ":default" {
+			if hasDefault {
+				return nil, errors.New("multiple default clauses in go:select")
+			}
+			def, hasDefault = c.cdr, true
+			continue
+		}
+		op := c.car
+		if op.t != NodeCell || op.car == nil {
+			return nil, errors.New("invalid arguments for go:select")
+		}
+		kind, _ := op.car.AsSymbol()
+		args := op.cdr
+		if args == nil || args.car == nil {
+			return nil, fmt.Errorf("invalid clause for go:select: %v", op)
+		}
+		v, err := eval(env, args.car)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case ":recv":
+			ch, err := chanOf(v, "go:select")
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: ch})
+			clauses = append(clauses, selectClause{vars: args.cdr, body: c.cdr})
+		case ":send":
+			ch, err := chanOf(v, "go:select")
+			if err != nil {
+				return nil, err
+			}
+			if args.cdr == nil || args.cdr.car == nil {
+				return nil, fmt.Errorf("invalid clause for go:select: %v", op)
+			}
+			sv, err := eval(env, args.cdr.car)
+			if err != nil {
+				return nil, err
+			}
+			rv, err := nodeToValue(sv, ch.Type().Elem())
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: ch, Send: rv})
+			clauses = append(clauses, selectClause{body: c.cdr})
+		case ":timeout":
+			d, err := duration(v)
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(time.After(d))})
+			clauses = append(clauses, selectClause{body: c.cdr})
+		default:
+			return nil, fmt.Errorf("invalid clause for go:select: %v", op)
+		}
+	}
+	if hasDefault {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+		clauses = append(clauses, selectClause{body: def})
+	}
+
+	chosen, rv, ok := reflect.Select(cases)
+	if chosen == 0 {
+		return nil, env.canceled()
+	}
+	clause := clauses[chosen]
+	scope := NewEnv(env)
+	if clause.vars != nil && clause.vars.car != nil {
+		scope.putVar(clause.vars.car.v.(*symbol), valueToNode(rv))
+		if clause.vars.cdr != nil && clause.vars.cdr.car != nil {
+			scope.putVar(clause.vars.cdr.car.v.(*symbol), Bool(ok))
+		}
+	}
+	ret, err := evalForms(scope, clause.body)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		ret = &Node{
+			t: NodeNil,
+		}
+	}
+	return ret, nil
+}
+
+func doGoChanClose(env *Env, node *Node) (rret *Node, rerr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			rerr = errors.New(fmt.Sprint(err))
+		}
+	}()
+	ch, err := chanOf(node.car, "go:chan-close")
+	if err != nil {
+		return nil, err
+	}
+	ch.Close()
+	return &Node{
+		t: NodeNil,
+	}, nil
+}
+
+func doGoChanLen(env *Env, node *Node) (*Node, error) {
+	ch, err := chanOf(node.car, "go:chan-len")
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		t: NodeInt,
+		v: int64(ch.Len()),
+	}, nil
+}
+
+// doDoChan receives from a channel until it is closed.
+//
+//	(do-chan (v ch) body...)
+func doDoChan(env *Env, node *Node) (rret *Node, rerr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			rerr = errors.New(fmt.Sprint(err))
+		}
+	}()
+	if node.car == nil || node.car.t != NodeCell || node.car.car == nil || node.car.car.t != NodeIdent || node.car.cdr == nil {
+		return nil, errors.New("invalid arguments for do-chan")
+	}
+	name := node.car.car.v.(*symbol)
+	v, err := eval(env, node.car.cdr.car)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := chanOf(v, "do-chan")
+	if err != nil {
+		return nil, err
+	}
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(env.done())},
+	}
+	for {
+		chosen, rv, ok := reflect.Select(cases)
+		if chosen == 1 {
+			return nil, env.canceled()
+		}
+		if !ok {
+			break
+		}
+		scope := NewEnv(env)
+		scope.putVar(name, valueToNode(rv))
+		if _, err := evalForms(scope, node.cdr); err != nil {
+			return nil, err
+		}
+	}
+	return &Node{
+		t: NodeNil,
+	}, nil
+}
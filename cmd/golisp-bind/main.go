@@ -0,0 +1,198 @@
+// Command golisp-bind generates a gopkg registration file for a Go package,
+// so that it can be imported with go:import.
+//
+//	golisp-bind [-tags tags] [-package name] [-o file] importpath
+//
+// Exported functions, variables, constants and types are registered.
+// Generic functions and types are skipped since they can not be
+// referenced without instantiation.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/constant"
+	"go/format"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type options struct {
+	tags    []string
+	pkgName string
+	dir     string
+}
+
+func load(path string, opts options) (*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes,
+		Dir:  opts.dir,
+	}
+	if len(opts.tags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(opts.tags, ",")}
+	}
+	pkgs, err := packages.Load(cfg, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%v matches %d packages", path, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, pkg.Errors[0]
+	}
+	return pkg, nil
+}
+
+func generic(obj types.Object) bool {
+	switch t := obj.Type().(type) {
+	case *types.Signature:
+		return t.TypeParams().Len() > 0
+	case *types.Named:
+		return t.TypeParams().Len() > 0 && t.TypeArgs().Len() == 0
+	}
+	return false
+}
+
+// constValue returns the expression registering the constant c. Untyped
+// constants are converted to the type Lisp uses for them.
+func constValue(c *types.Const, name string) (string, bool) {
+	b, ok := c.Type().(*types.Basic)
+	if !ok || b.Info()&types.IsUntyped == 0 {
+		return name, true
+	}
+	switch c.Val().Kind() {
+	case constant.Int:
+		if _, ok := constant.Int64Val(c.Val()); ok {
+			return "int64(" + name + ")", true
+		}
+		if _, ok := constant.Uint64Val(c.Val()); ok {
+			return "uint64(" + name + ")", true
+		}
+		return "", false
+	case constant.Float:
+		return "float64(" + name + ")", true
+	case constant.Complex:
+		return "complex128(" + name + ")", true
+	}
+	return name, true
+}
+
+func generate(w io.Writer, pkg *packages.Package, opts options) error {
+	if pkg.Types == nil {
+		return errors.New("no type information for " + pkg.PkgPath)
+	}
+	alias := pkg.Name
+	if alias == "reflect" || alias == "gopkg" || alias == opts.pkgName {
+		alias = "_" + alias
+	}
+	qualifier := ""
+	if opts.pkgName != "gopkg" {
+		qualifier = "gopkg."
+	}
+
+	var values, typs []string
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() || generic(obj) {
+			continue
+		}
+		ref := alias + "." + name
+		switch obj := obj.(type) {
+		case *types.Func, *types.Var:
+			values = append(values, fmt.Sprintf("%q: reflect.ValueOf(%s),", name, ref))
+		case *types.Const:
+			if v, ok := constValue(obj, ref); ok {
+				values = append(values, fmt.Sprintf("%q: reflect.ValueOf(%s),", name, v))
+			}
+		case *types.TypeName:
+			typs = append(typs, fmt.Sprintf("%q: reflect.TypeOf((*%s)(nil)).Elem(),", name, ref))
+		}
+	}
+	sort.Strings(values)
+	sort.Strings(typs)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by golisp-bind; DO NOT EDIT.\n\n")
+	if len(opts.tags) > 0 {
+		fmt.Fprintf(&buf, "//go:build %s\n\n", strings.Join(opts.tags, " && "))
+	}
+	fmt.Fprintf(&buf, "package %s\n\n", opts.pkgName)
+	fmt.Fprintf(&buf, "import (\n\t\"reflect\"\n\n")
+	if alias != pkg.Name {
+		fmt.Fprintf(&buf, "\t%s %q\n", alias, pkg.PkgPath)
+	} else {
+		fmt.Fprintf(&buf, "\t%q\n", pkg.PkgPath)
+	}
+	if qualifier != "" {
+		fmt.Fprintf(&buf, "\t\"github.com/mattn/golisp/gopkg\"\n")
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+	fmt.Fprintf(&buf, "func init() {\n")
+	fmt.Fprintf(&buf, "%sPackages[%q] = map[string]reflect.Value{\n", qualifier, pkg.PkgPath)
+	for _, v := range values {
+		fmt.Fprintln(&buf, v)
+	}
+	fmt.Fprintf(&buf, "}\n")
+	if len(typs) > 0 {
+		fmt.Fprintf(&buf, "%sPackageTypes[%q] = map[string]reflect.Type{\n", qualifier, pkg.PkgPath)
+		for _, t := range typs {
+			fmt.Fprintln(&buf, t)
+		}
+		fmt.Fprintf(&buf, "}\n")
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func main() {
+	var tags, out string
+	var opts options
+	flag.StringVar(&tags, "tags", "", "comma-separated build tags")
+	flag.StringVar(&opts.pkgName, "package", "gopkg", "package name of the generated file")
+	flag.StringVar(&out, "o", "", "output file (default stdout)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: golisp-bind [flags] importpath\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if tags != "" {
+		opts.tags = strings.Split(tags, ",")
+	}
+
+	pkg, err := load(flag.Arg(0), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := generate(&buf, pkg, opts); err != nil {
+		log.Fatal(err)
+	}
+	if out == "" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := os.WriteFile(out, buf.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	pkg, err := load(".", options{dir: "testdata/shapes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := generate(&buf, pkg, options{pkgName: "gopkg"}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`Packages["example.com/shapes"] = map[string]reflect.Value{`,
+		`"NewSquare": reflect.ValueOf(shapes.NewSquare),`,
+		`"Default":   reflect.ValueOf(shapes.Default),`,
+		`"Sides":     reflect.ValueOf(int64(shapes.Sides)),`,
+		`"Big":       reflect.ValueOf(uint64(shapes.Big)),`,
+		`"Ratio":     reflect.ValueOf(float64(shapes.Ratio)),`,
+		`"Unit":      reflect.ValueOf(shapes.Unit),`,
+		`"Shape":  reflect.TypeOf((*shapes.Shape)(nil)).Elem(),`,
+		`"Square": reflect.TypeOf((*shapes.Square)(nil)).Elem(),`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"Max", "Pair", "helper", "hidden", "Extra", "+build", "gopkg\""} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("unwanted %q in:\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestGenerateTags(t *testing.T) {
+	opts := options{tags: []string{"extra"}, pkgName: "bindings", dir: "testdata/shapes"}
+	pkg, err := load(".", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := generate(&buf, pkg, opts); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"//go:build extra\n",
+		"package bindings\n",
+		`"github.com/mattn/golisp/gopkg"`,
+		`gopkg.Packages["example.com/shapes"]`,
+		`"Extra":`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in:\n%s", want, got)
+		}
+	}
+}
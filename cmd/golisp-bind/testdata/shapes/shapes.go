@@ -0,0 +1,42 @@
+// Package shapes is a fixture for golisp-bind.
+package shapes
+
+import "math"
+
+const (
+	Sides        = 4
+	Big          = math.MaxUint64
+	Ratio        = 1.5
+	Name         = "shapes"
+	Unit   Scale = 2
+	hidden       = 1
+)
+
+var Default = Square{Side: 1}
+
+type Scale int
+
+type Square struct {
+	Side float64
+}
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+
+type Shape interface {
+	Area() float64
+}
+
+type Pair[T any] struct {
+	A, B T
+}
+
+func NewSquare(side float64) *Square { return &Square{Side: side} }
+
+func Max[T int | float64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func helper() {}
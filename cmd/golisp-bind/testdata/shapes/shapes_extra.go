@@ -0,0 +1,5 @@
+//go:build extra
+
+package shapes
+
+func Extra() string { return "extra" }
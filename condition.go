@@ -0,0 +1,125 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// fatal reports whether err must not be handled by scripts: cancellation
+// and the limits of the sandbox.
+func fatal(err error) bool {
+	for _, target := range []error{ErrCanceled, ErrStepLimit, ErrDepthLimit, ErrConsLimit, ErrOutputLimit, ErrTimeLimit} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// doError signals an error with a message, or signals a condition or a Go
+// error value again.
+//
+//	(error "message")
+func doError(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for error")
+	}
+	if err, ok := goError(node.car); ok {
+		return nil, err
+	}
+	switch node.car.t {
+	case NodeError:
+		return nil, node.car.v.(error)
+	case NodeString:
+		return nil, errors.New(node.car.v.(string))
+	}
+	return nil, errors.New(fmt.Sprint(node.car))
+}
+
+// goError returns the Go error held by node, such as the value of io.EOF.
+func goError(node *Node) (error, bool) {
+	if node.t == NodeCell && node.car != nil && node.car.t == NodeGoValue && node.CdrIsNil() {
+		node = node.car
+	}
+	if node.t != NodeGoValue {
+		return nil, false
+	}
+	rv := node.v.(reflect.Value)
+	if !rv.IsValid() || !rv.CanInterface() {
+		return nil, false
+	}
+	err, ok := rv.Interface().(error)
+	return err, ok && err != nil
+}
+
+func doErrorMessage(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.car.t != NodeError {
+		return nil, errors.New("invalid arguments for error-message")
+	}
+	return &Node{
+		t: NodeString,
+		v: node.car.v.(error).Error(),
+	}, nil
+}
+
+// doHandlerCase evaluates a form, and evaluates the clause for error with
+// the condition bound to a variable if the form signals an error.
+//
+//	(handler-case form
+//	  (error (c) (error-message c)))
+func doHandlerCase(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for handler-case")
+	}
+	ret, err := eval(env, node.car)
+	if err == nil {
+		if ret == nil {
+			ret = &Node{
+				t: NodeNil,
+			}
+		}
+		return ret, nil
+	}
+	if fatal(err) {
+		return nil, err
+	}
+	for curr := node.cdr; curr != nil && curr.car != nil; curr = curr.cdr {
+		clause := curr.car
+		if clause.t != NodeCell || clause.car == nil || clause.cdr == nil {
+			return nil, errors.New("invalid clause for handler-case")
+		}
+		switch clause.car.t {
+		case NodeT:
+		case NodeIdent:
+			if name, _ := clause.car.AsSymbol(); name != "error" {
+				return nil, fmt.Errorf("invalid condition type: %v", clause.car)
+			}
+		default:
+			return nil, fmt.Errorf("invalid condition type: %v", clause.car)
+		}
+		scope := NewEnv(env)
+		vars := clause.cdr.car
+		if vars != nil && vars.t == NodeCell && vars.car != nil {
+			s, ok := symbolOf(vars.car)
+			if !ok {
+				return nil, errors.New("invalid clause for handler-case")
+			}
+			scope.putVar(s, &Node{
+				t: NodeError,
+				v: err,
+			})
+		}
+		ret, err := evalForms(scope, clause.cdr.cdr)
+		if err != nil {
+			return nil, err
+		}
+		if ret == nil {
+			ret = &Node{
+				t: NodeNil,
+			}
+		}
+		return ret, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,70 @@
+package golisp
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrCanceled matches, with errors.Is, the errors returned when evaluation
+// stops because its context is done.
+var ErrCanceled = errors.New("evaluation canceled")
+
+// CancelError is returned when evaluation stops because its context is
+// done. It unwraps to the error of the context.
+type CancelError struct {
+	Err error
+}
+
+func (e *CancelError) Error() string {
+	return ErrCanceled.Error() + ": " + e.Err.Error()
+}
+
+func (e *CancelError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CancelError) Is(target error) bool {
+	return target == ErrCanceled
+}
+
+// EvalContext evaluates the forms of node in e like Eval, and stops with a
+// *CancelError as soon as ctx is done. Goroutines started with go during
+// the evaluation stop too. The forms are evaluated in a frame of their own
+// that carries ctx, so evaluations in e may run concurrently with
+// different contexts.
+func (e *Env) EvalContext(ctx context.Context, node *Node) (*Node, error) {
+	scope := NewEnv(e)
+	scope.top = true
+	cancel := e.limitScope(scope, ctx)
+	defer cancel()
+	ret, err := evalForms(scope, node)
+	// in-package at the top level changes the package of e.
+	if scope.pkg != e.pkg {
+		e.pkg = scope.pkg
+	}
+	if err = scope.sandboxError(ctx, err); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// canceled returns a *CancelError if the context of e is done.
+func (e *Env) canceled() error {
+	if e.ctx == nil {
+		return nil
+	}
+	select {
+	case <-e.ctx.Done():
+		return &CancelError{Err: e.ctx.Err()}
+	default:
+		return nil
+	}
+}
+
+// done returns the channel closed when the evaluation in e is canceled.
+func (e *Env) done() <-chan struct{} {
+	if e.ctx == nil {
+		return nil
+	}
+	return e.ctx.Done()
+}
@@ -0,0 +1,114 @@
+package golisp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvalContext(t *testing.T) {
+	env := NewEnv(nil)
+	node, err := NewParser(strings.NewReader(`(setq i 0) (while t (setq i (+ i 1)))`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = env.EvalContext(ctx, node)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("want ErrCanceled but got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want context.DeadlineExceeded but got %v", err)
+	}
+
+	if _, err := env.EvalString(context.Background(), `(+ i 1)`); err != nil {
+		t.Fatalf("env should be usable after cancel: %v", err)
+	}
+}
+
+func TestEvalContextChan(t *testing.T) {
+	tests := []string{
+		`(go:chan-recv (go:make-chan int))`,
+		`(go:chan-send (go:make-chan int) 1)`,
+		`(go:select ((:recv (go:make-chan int) v) v))`,
+		`(do-chan (v (go:make-chan int)) v)`,
+	}
+	for _, test := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := NewEnv(nil).EvalString(ctx, test)
+		cancel()
+		if !errors.Is(err, ErrCanceled) {
+			t.Errorf("want ErrCanceled for %q but got %v", test, err)
+		}
+	}
+}
+
+func TestEvalContextGo(t *testing.T) {
+	env := NewEnv(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := env.EvalString(ctx, `
+(setq ch (go:make-chan int))
+(go (while t (go:chan-send ch 1)))
+(go:chan-recv ch)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = env.EvalString(ctx, `(go:chan-recv ch)`)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("goroutine should be stopped, but got %v", err)
+	}
+}
+
+func TestEvalContextConcurrent(t *testing.T) {
+	env := NewEnv(nil)
+	if _, err := env.EvalString(context.Background(), `(defun spin () (while t))`); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := env.EvalString(ctx, `(spin)`)
+		errc <- err
+	}()
+	for i := 0; i < 10; i++ {
+		ret, err := env.EvalString(context.Background(), `(setq n (+ 1 2))`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := ret.AsInt(); v != 3 {
+			t.Fatalf("want 3 but got %v", ret)
+		}
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, ErrCanceled) {
+		t.Fatalf("want ErrCanceled but got %v", err)
+	}
+	if v, ok := env.Lookup("n"); !ok || v.String() != "3" {
+		t.Fatalf("want n bound in env but got %v", v)
+	}
+}
+
+func TestEvalContextOut(t *testing.T) {
+	env := NewEnv(nil)
+	if _, err := env.EvalString(context.Background(), `(defun hello () (princ "hello"))`); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	env.SetOut(&buf)
+	if _, err := env.EvalString(context.Background(), `(hello)`); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "hello" {
+		t.Fatalf("want %q but got %q", "hello", got)
+	}
+}
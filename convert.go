@@ -0,0 +1,244 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+var (
+	nodeType  = reflect.TypeOf((*Node)(nil))
+	envType   = reflect.TypeOf((*Env)(nil))
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// valueToNode converts a Go value of a basic kind to a native node. Other
+// values, and unsigned integers too large for int64, are wrapped as
+// NodeGoValue.
+func valueToNode(rv reflect.Value) *Node {
+	if !rv.IsValid() {
+		return &Node{
+			t: NodeNil,
+		}
+	}
+	if rv.Type() == nodeType {
+		if n := rv.Interface().(*Node); n != nil {
+			return n
+		}
+		return &Node{
+			t: NodeNil,
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Node{
+			t: NodeInt,
+			v: rv.Int(),
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		// Values above math.MaxInt64 stay Go values rather than wrapping.
+		if rv.Uint() <= math.MaxInt64 {
+			return &Node{
+				t: NodeInt,
+				v: int64(rv.Uint()),
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		return &Node{
+			t: NodeDouble,
+			v: rv.Float(),
+		}
+	case reflect.String:
+		return &Node{
+			t: NodeString,
+			v: rv.String(),
+		}
+	case reflect.Bool:
+		if rv.Bool() {
+			return &Node{
+				t: NodeT,
+				v: true,
+			}
+		}
+		return &Node{
+			t: NodeNil,
+		}
+	case reflect.Interface:
+		if rv.IsNil() {
+			return &Node{
+				t: NodeNil,
+			}
+		}
+		return valueToNode(rv.Elem())
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if rv.IsNil() {
+			return &Node{
+				t: NodeNil,
+			}
+		}
+	}
+	return &Node{
+		t: NodeGoValue,
+		v: rv,
+	}
+}
+
+// nodeInterface returns the natural Go value of a native node.
+func nodeInterface(node *Node) interface{} {
+	switch node.t {
+	case NodeNil:
+		return nil
+	case NodeT:
+		return true
+	case NodeIdent:
+		return node.v.(*symbol).name
+	case NodeGoValue:
+		rv := node.v.(reflect.Value)
+		if !rv.IsValid() {
+			return nil
+		}
+		return rv.Interface()
+	case NodeHash:
+		ret := map[string]interface{}{}
+		node.v.(*HashTable).Each(func(k, v *Node) error {
+			name, ok := designatorName(k)
+			if !ok {
+				name = k.String()
+			}
+			ret[name] = nodeInterface(v)
+			return nil
+		})
+		return ret
+	case NodeCell:
+		ret := []interface{}{}
+		for curr := node; curr != nil && curr.t == NodeCell && curr.car != nil; curr = curr.cdr {
+			ret = append(ret, nodeInterface(curr.car))
+		}
+		return ret
+	case NodeVector:
+		ret := []interface{}{}
+		for _, item := range node.v.([]*Node) {
+			ret = append(ret, nodeInterface(item))
+		}
+		return ret
+	}
+	return node.v
+}
+
+// nodeToValue converts node to a Go value of type typ.
+func nodeToValue(node *Node, typ reflect.Type) (reflect.Value, error) {
+	if typ == nodeType {
+		return reflect.ValueOf(node), nil
+	}
+	rv := reflect.New(typ).Elem()
+	if err := unmarshalValue(node, rv); err != nil {
+		return reflect.Value{}, err
+	}
+	return rv, nil
+}
+
+// goArg converts an evaluated argument of a Go call to typ. A result list
+// of a previous Go call holding a single value is unwrapped, and a Lisp
+// function passed for a func parameter is wrapped with makeFunc.
+func goArg(env *Env, arg *Node, typ reflect.Type) (reflect.Value, error) {
+	if env != nil && typ.Kind() == reflect.Func && isFunction(env, arg) {
+		return makeFunc(env, arg, typ), nil
+	}
+	if arg.t == NodeCell && arg.car != nil && arg.car.t == NodeGoValue && arg.CdrIsNil() {
+		arg = arg.car
+	}
+	return nodeToValue(arg, typ)
+}
+
+// goArgs converts the argument list node to the parameters of a function of
+// type rt. A leading *Env parameter receives env. Lisp functions passed for
+// func parameters report their errors to call.
+func goArgs(env *Env, rt reflect.Type, node *Node, call *goCallErrors) ([]reflect.Value, error) {
+	numIn := rt.NumIn()
+	args := []reflect.Value{}
+	in := 0
+	if numIn > 0 && rt.In(0) == envType {
+		args = append(args, reflect.ValueOf(env))
+		in++
+	}
+	off := in
+	curr := node
+	for curr != nil && curr.t == NodeCell && curr.car != nil {
+		var at reflect.Type
+		if rt.IsVariadic() && in >= numIn-1 {
+			at = rt.In(numIn - 1).Elem()
+		} else if in < numIn {
+			at = rt.In(in)
+		} else {
+			return nil, fmt.Errorf("too many arguments: want %d", numIn-off)
+		}
+		var arg reflect.Value
+		var err error
+		if env != nil && at.Kind() == reflect.Func && isFunction(env, curr.car) {
+			arg = makeCallback(env, curr.car, at, call)
+		} else {
+			arg, err = goArg(env, curr.car, at)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", in-off+1, err)
+		}
+		args = append(args, arg)
+		in++
+		curr = curr.cdr
+	}
+	if rt.IsVariadic() {
+		numIn--
+	}
+	if in < numIn {
+		return nil, fmt.Errorf("too few arguments: want %d", numIn-off)
+	}
+	return args, nil
+}
+
+// goCall calls the Go function rv with the argument list node. A non-nil
+// trailing error result is returned as error. Other results are returned as
+// a single node, or a list of nodes if there are several.
+func goCall(env *Env, rv reflect.Value, node *Node) (rret *Node, rerr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			rerr = errors.New(fmt.Sprint(err))
+		}
+	}()
+	rt := rv.Type()
+	call := &goCallErrors{}
+	args, err := goArgs(env, rt, node, call)
+	if err != nil {
+		return nil, err
+	}
+	rets := rv.Call(args)
+	if err := call.finish(); err != nil {
+		return nil, err
+	}
+	if n := rt.NumOut(); n > 0 && rt.Out(n-1) == errorType {
+		if err, _ := rets[n-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		rets = rets[:n-1]
+	}
+	switch len(rets) {
+	case 0:
+		return &Node{
+			t: NodeNil,
+		}, nil
+	case 1:
+		return valueToNode(rets[0]), nil
+	}
+	head := &Node{
+		t: NodeCell,
+	}
+	curr := head
+	for _, ret := range rets {
+		curr.cdr = &Node{
+			t:   NodeCell,
+			car: valueToNode(ret),
+		}
+		curr = curr.cdr
+	}
+	return head.cdr, nil
+}
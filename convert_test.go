@@ -0,0 +1,74 @@
+package golisp
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type counter struct {
+	n    int8
+	last interface{}
+}
+
+func (c *counter) Add(n int8) int8 {
+	c.n += n
+	return c.n
+}
+
+func (c *counter) Set(v interface{}) {
+	c.last = v
+}
+
+func (c *counter) Sum(base int, ns ...int) int {
+	for _, n := range ns {
+		base += n
+	}
+	return base
+}
+
+func TestGoMethodCall(t *testing.T) {
+	env := NewEnv(nil)
+	c := &counter{}
+	env.Define("c", c)
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`(.Add c 3)`, "(3)"},
+		{`(.Sum c 1)`, "(1)"},
+		{`(.Sum c 1 2 3 4)`, "(10)"},
+		{`(.Set c nil)`, "nil"},
+	}
+	for _, test := range tests {
+		ret, err := env.EvalString(context.Background(), test.src)
+		if err != nil {
+			t.Fatalf("%s: %v", test.src, err)
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s: want %v but got %v", test.src, test.want, got)
+		}
+	}
+	if c.last != nil {
+		t.Fatalf("want nil interface but got %#v", c.last)
+	}
+
+	errs := []struct {
+		src  string
+		want string
+	}{
+		{`(.Add c 1000)`, "overflows"},
+		{`(.Add c "a")`, "argument 1"},
+		{`(.Add c)`, "too few arguments"},
+		{`(.Add c 1 2)`, "too many arguments"},
+		{`(.Sum c 1 "a")`, "argument 2"},
+		{`(.NoSuchMethod c)`, "invalid method name"},
+	}
+	for _, test := range errs {
+		_, err := env.EvalString(context.Background(), test.src)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: want error containing %q but got %v", test.src, test.want, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package golisp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestGoConcurrentSetq(t *testing.T) {
+	env := NewEnv(nil)
+	ret, err := env.EvalString(context.Background(), `
+(setq n 0)
+(setq done (go:make-chan int))
+(defun worker (i)
+  (dotimes (j 100)
+    (setq n (+ n 1))
+    (setq last i))
+  (go:chan-send done i))
+(dotimes (i 8) (go (worker i)))
+(dotimes (i 8) (go:chan-recv done))
+(> n 0)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ret.AsBool(); !b {
+		t.Fatalf("want t but got %v", ret)
+	}
+}
+
+func TestGoError(t *testing.T) {
+	env := NewEnv(nil)
+	var mu sync.Mutex
+	var errs []error
+	env.SetGoErrorHandler(func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+	ret, err := env.EvalString(context.Background(), `(go:chan-recv (go (undefined-function)))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, ok := ret.Car().AsGoValue()
+	if !ok {
+		t.Fatalf("want go value but got %v", ret)
+	}
+	if _, ok := v.(error); !ok {
+		t.Fatalf("want error but got %v", reflect.TypeOf(v))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 || !errors.Is(errs[0], v.(error)) {
+		t.Fatalf("want the error to be reported but got %v", errs)
+	}
+}
@@ -0,0 +1,184 @@
+package golisp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// future is the result of code evaluated in its own goroutine.
+type future struct {
+	done chan struct{}
+	ret  *Node
+	err  error
+}
+
+// taskGroup waits for the goroutines started in with-task-group and
+// cancels them when one fails.
+type taskGroup struct {
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+	once   sync.Once
+	err    error
+}
+
+func newTaskGroup(env *Env) (*Env, *taskGroup) {
+	ctx, cancel := context.WithCancel(env.ctx)
+	g := &taskGroup{cancel: cancel}
+	scope := NewEnv(env)
+	scope.ctx = ctx
+	scope.group = g
+	return scope, g
+}
+
+func (g *taskGroup) fail(err error) {
+	g.once.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
+
+// wait waits for all goroutines of g and returns the first error.
+func (g *taskGroup) wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// spawn evaluates fn in a new goroutine with a child of env. A goroutine
+// started in a task group joins it, and its error fails the group;
+// otherwise errors are passed to the error handler of the interpreter if
+// report is set. errc, if not nil, receives the error when fn returns.
+func spawn(env *Env, report bool, errc chan error, fn func(*Env) (*Node, error)) *future {
+	f := &future{done: make(chan struct{})}
+	g := env.group
+	if g != nil {
+		g.wg.Add(1)
+	}
+	go func(env *Env) {
+		defer func() {
+			if e := recover(); e != nil {
+				f.ret, f.err = nil, errors.New(fmt.Sprint(e))
+			}
+			if f.err != nil {
+				if g != nil {
+					g.fail(f.err)
+				} else if report {
+					env.goError(f.err)
+				}
+			}
+			close(f.done)
+			if errc != nil {
+				errc <- f.err
+				close(errc)
+			}
+			if g != nil {
+				g.wg.Done()
+			}
+		}()
+		f.ret, f.err = fn(env)
+	}(NewEnv(env))
+	return f
+}
+
+// await waits for f to finish, or for env to be canceled.
+func (f *future) await(env *Env) (*Node, error) {
+	select {
+	case <-f.done:
+	case <-env.done():
+		return nil, env.canceled()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.ret == nil {
+		return &Node{
+			t: NodeNil,
+		}, nil
+	}
+	return f.ret, nil
+}
+
+func doFuture(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for future")
+	}
+	f := spawn(env, false, nil, func(env *Env) (*Node, error) {
+		return evalForms(env, node)
+	})
+	return &Node{
+		t: NodeGoValue,
+		v: reflect.ValueOf(f),
+	}, nil
+}
+
+func doAwait(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.car.t != NodeGoValue {
+		return nil, errors.New("invalid arguments for await")
+	}
+	f, ok := node.car.v.(reflect.Value).Interface().(*future)
+	if !ok {
+		return nil, errors.New("invalid arguments for await")
+	}
+	return f.await(env)
+}
+
+func doPmap(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for pmap")
+	}
+	fn := node.car
+	scope, g := newTaskGroup(env)
+	var fs []*future
+	for curr := node.cdr.car; curr != nil && curr.t == NodeCell && curr.car != nil; curr = curr.cdr {
+		arg := curr.car
+		fs = append(fs, spawn(scope, false, nil, func(env *Env) (*Node, error) {
+			return eval(env, &Node{
+				t:   NodeCell,
+				car: fn,
+				cdr: &Node{
+					t: NodeCell,
+					car: &Node{
+						t:   NodeQuote,
+						car: arg,
+					},
+				},
+			})
+		}))
+	}
+	if err := g.wait(); err != nil {
+		return nil, err
+	}
+	if err := env.alloc(int64(len(fs))); err != nil {
+		return nil, err
+	}
+	rets := make([]*Node, len(fs))
+	for i, f := range fs {
+		rets[i] = f.ret
+		if rets[i] == nil {
+			rets[i] = &Node{
+				t: NodeNil,
+			}
+		}
+	}
+	return List(rets...), nil
+}
+
+func doWithTaskGroup(env *Env, node *Node) (*Node, error) {
+	scope, g := newTaskGroup(env)
+	ret, err := evalForms(scope, node)
+	if err != nil {
+		g.fail(err)
+	}
+	if err := g.wait(); err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		ret = &Node{
+			t: NodeNil,
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,48 @@
+package golisp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskGroupError(t *testing.T) {
+	env := NewEnv(nil)
+	start := time.Now()
+	_, err := env.EvalString(context.Background(), `
+(with-task-group
+  (go (go:chan-recv (go:make-chan int)))
+  (go (undefined-function)))
+`)
+	if err == nil || !strings.Contains(err.Error(), "undefined-function") {
+		t.Fatalf("want error of undefined-function but got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("task group should cancel the blocked goroutine")
+	}
+}
+
+func TestPmapError(t *testing.T) {
+	env := NewEnv(nil)
+	_, err := env.EvalString(context.Background(), `(pmap (lambda (x) (car x)) '(1 2 3))`)
+	if err == nil {
+		t.Fatal("want error")
+	}
+}
+
+func TestAwaitError(t *testing.T) {
+	env := NewEnv(nil)
+	_, err := env.EvalString(context.Background(), `(await (future (undefined-function)))`)
+	if err == nil || !strings.Contains(err.Error(), "undefined-function") {
+		t.Fatalf("want error of undefined-function but got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = env.EvalString(ctx, `(await (future (go:chan-recv (go:make-chan int))))`)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("want ErrCanceled but got %v", err)
+	}
+}
@@ -1,15 +1,9 @@
 module github.com/mattn/golisp
 
-go 1.14
+go 1.16
 
 require (
-	github.com/go-toast/toast v0.0.0-20190211030409-01e6764cf0a4 // indirect
 	github.com/google/go-cmp v0.4.0
-	github.com/mattn/anko v0.1.6
 	github.com/mattn/go-isatty v0.0.12
-	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d // indirect
-	github.com/rakyll/statik v0.1.7
-	github.com/variadico/noti v2.7.0+incompatible // indirect
 	golang.org/x/sys v0.0.0-20200223170610-d5e6a3e2c0ae // indirect
-	golang.org/x/tools v0.0.0-20200403190813-44a64ad78b9b // indirect
 )
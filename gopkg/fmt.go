@@ -27,4 +27,7 @@ func init() {
 		"Sscanf":   reflect.ValueOf(fmt.Sscanf),
 		"Sscanln":  reflect.ValueOf(fmt.Sscanln),
 	}
+	PackageTypes["fmt"] = map[string]reflect.Type{
+		"Stringer": reflect.TypeOf((*fmt.Stringer)(nil)).Elem(),
+	}
 }
@@ -25,4 +25,9 @@ func init() {
 		"TeeReader":        reflect.ValueOf(io.TeeReader),
 		"WriteString":      reflect.ValueOf(io.WriteString),
 	}
+	PackageTypes["io"] = map[string]reflect.Type{
+		"Closer": reflect.TypeOf((*io.Closer)(nil)).Elem(),
+		"Reader": reflect.TypeOf((*io.Reader)(nil)).Elem(),
+		"Writer": reflect.TypeOf((*io.Writer)(nil)).Elem(),
+	}
 }
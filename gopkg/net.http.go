@@ -22,9 +22,11 @@ func init() {
 		"SetCookie":         reflect.ValueOf(http.SetCookie),
 	}
 	PackageTypes["net/http"] = map[string]reflect.Type{
-		"Client":   reflect.TypeOf(http.Client{}),
-		"Cookie":   reflect.TypeOf(http.Cookie{}),
-		"Request":  reflect.TypeOf(http.Request{}),
-		"Response": reflect.TypeOf(http.Response{}),
+		"Client":         reflect.TypeOf(http.Client{}),
+		"Cookie":         reflect.TypeOf(http.Cookie{}),
+		"Handler":        reflect.TypeOf((*http.Handler)(nil)).Elem(),
+		"Request":        reflect.TypeOf(http.Request{}),
+		"Response":       reflect.TypeOf(http.Response{}),
+		"ResponseWriter": reflect.TypeOf((*http.ResponseWriter)(nil)).Elem(),
 	}
 }
@@ -34,6 +34,7 @@ func init() {
 	}
 	PackageTypes["sort"] = map[string]reflect.Type{
 		"Float64Slice":    reflect.TypeOf(sort.Float64Slice{}),
+		"Interface":       reflect.TypeOf((*sort.Interface)(nil)).Elem(),
 		"IntSlice":        reflect.TypeOf(sort.IntSlice{}),
 		"StringSlice":     reflect.TypeOf(sort.StringSlice{}),
 		"SortFuncsStruct": reflect.TypeOf(&SortFuncsStruct{}),
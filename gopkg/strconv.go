@@ -7,13 +7,17 @@ import (
 
 func init() {
 	Packages["strconv"] = map[string]reflect.Value{
+		"Atoi":        reflect.ValueOf(strconv.Atoi),
 		"FormatBool":  reflect.ValueOf(strconv.FormatBool),
 		"FormatFloat": reflect.ValueOf(strconv.FormatFloat),
 		"FormatInt":   reflect.ValueOf(strconv.FormatInt),
 		"FormatUint":  reflect.ValueOf(strconv.FormatUint),
+		"Itoa":        reflect.ValueOf(strconv.Itoa),
 		"ParseBool":   reflect.ValueOf(strconv.ParseBool),
 		"ParseFloat":  reflect.ValueOf(strconv.ParseFloat),
 		"ParseInt":    reflect.ValueOf(strconv.ParseInt),
 		"ParseUint":   reflect.ValueOf(strconv.ParseUint),
+		"Quote":       reflect.ValueOf(strconv.Quote),
+		"Unquote":     reflect.ValueOf(strconv.Unquote),
 	}
 }
@@ -0,0 +1,361 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mattn/golisp/gopkg"
+)
+
+// lookupType resolves a type name like int or net/http.Request against
+// gopkg.BasicTypes and the package types registered in env or gopkg.
+func lookupType(env *Env, name string) (reflect.Type, bool, error) {
+	if typ, ok := gopkg.BasicTypes[name]; ok {
+		return typ, true, nil
+	}
+	if name == "error" {
+		return errorType, true, nil
+	}
+	i := strings.LastIndexByte(name, '.')
+	if i <= 0 {
+		return nil, false, nil
+	}
+	typ, known := env.goPackageType(name[:i], name[i+1:])
+	if !known {
+		return nil, false, nil
+	}
+	if typ == nil {
+		return nil, false, fmt.Errorf("invalid type name: %v", name)
+	}
+	if err := env.allowPackage(name[:i]); err != nil {
+		return nil, false, err
+	}
+	return typ, true, nil
+}
+
+// goType resolves the type expression node. A type expression is a type
+// name, (ptr T), (slice T), (array N T), (map K V) or (chan T). Other
+// expressions are evaluated and must yield a type made by go:type.
+func goType(env *Env, node *Node) (reflect.Type, error) {
+	if node == nil {
+		return nil, errors.New("invalid type")
+	}
+	switch node.t {
+	case NodeQuote:
+		return goType(env, node.car)
+	case NodeIdent:
+		typ, ok, err := lookupType(env, node.v.(*symbol).name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return typ, nil
+		}
+	case NodeCell:
+		if node.car != nil && node.car.t == NodeIdent {
+			if typ, ok, err := compositeType(env, node); ok || err != nil {
+				return typ, err
+			}
+		}
+	case NodeGoValue:
+		if typ, ok := typeOf(node); ok {
+			return typ, nil
+		}
+		return nil, fmt.Errorf("invalid type: %v", node)
+	}
+	v, err := eval(env, node)
+	if err != nil {
+		return nil, err
+	}
+	if typ, ok := typeOf(v); ok {
+		return typ, nil
+	}
+	return nil, fmt.Errorf("invalid type: %v", node)
+}
+
+func compositeType(env *Env, node *Node) (reflect.Type, bool, error) {
+	args := node.cdr.ToSlice()
+	var elem reflect.Type
+	var err error
+	switch node.car.v.(*symbol).name {
+	case "ptr", "slice", "chan":
+		if len(args) != 1 {
+			return nil, true, fmt.Errorf("invalid type: %v", node)
+		}
+		elem, err = goType(env, args[0])
+	case "array", "map":
+		if len(args) != 2 {
+			return nil, true, fmt.Errorf("invalid type: %v", node)
+		}
+		elem, err = goType(env, args[1])
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	switch node.car.v.(*symbol).name {
+	case "ptr":
+		return reflect.PtrTo(elem), true, nil
+	case "slice":
+		return reflect.SliceOf(elem), true, nil
+	case "chan":
+		return reflect.ChanOf(reflect.BothDir, elem), true, nil
+	case "array":
+		n, ok := args[0].AsInt()
+		if !ok || n < 0 {
+			return nil, true, fmt.Errorf("invalid array length: %v", args[0])
+		}
+		return reflect.ArrayOf(int(n), elem), true, nil
+	}
+	key, err := goType(env, args[0])
+	if err != nil {
+		return nil, true, err
+	}
+	if !key.Comparable() {
+		return nil, true, fmt.Errorf("invalid map key type: %v", key)
+	}
+	return reflect.MapOf(key, elem), true, nil
+}
+
+// typeOf returns the type wrapped by node.
+func typeOf(node *Node) (reflect.Type, bool) {
+	if node == nil || node.t != NodeGoValue {
+		return nil, false
+	}
+	rv := node.v.(reflect.Value)
+	if !rv.IsValid() || !rv.CanInterface() {
+		return nil, false
+	}
+	typ, ok := rv.Interface().(reflect.Type)
+	return typ, ok && typ != nil
+}
+
+// intArg evaluates node as an int.
+func intArg(env *Env, node *Node, name string) (int, error) {
+	v, err := eval(env, node)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.AsInt()
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("invalid size for %v: %v", name, v)
+	}
+	return int(n), nil
+}
+
+func doGoType(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for go:type")
+	}
+	typ, err := goType(env, node.car)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		t: NodeGoValue,
+		v: reflect.ValueOf(&typ).Elem(),
+	}, nil
+}
+
+func doGoMakeSlice(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for go:make-slice")
+	}
+	typ, err := goType(env, node.car)
+	if err != nil {
+		return nil, err
+	}
+	n, err := intArg(env, node.cdr.car, "go:make-slice")
+	if err != nil {
+		return nil, err
+	}
+	c := n
+	if node.cdr.cdr != nil && node.cdr.cdr.car != nil {
+		c, err = intArg(env, node.cdr.cdr.car, "go:make-slice")
+		if err != nil {
+			return nil, err
+		}
+		if c < n {
+			return nil, errors.New("len larger than cap for go:make-slice")
+		}
+	}
+	return &Node{
+		t: NodeGoValue,
+		v: reflect.MakeSlice(reflect.SliceOf(typ), n, c),
+	}, nil
+}
+
+func doGoMakeMap(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for go:make-map")
+	}
+	key, err := goType(env, node.car)
+	if err != nil {
+		return nil, err
+	}
+	elem, err := goType(env, node.cdr.car)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Comparable() {
+		return nil, fmt.Errorf("invalid map key type: %v", key)
+	}
+	return &Node{
+		t: NodeGoValue,
+		v: reflect.MakeMap(reflect.MapOf(key, elem)),
+	}, nil
+}
+
+// doGoNew returns a pointer to a new value of a type. Fields of a struct
+// are set from keyword arguments.
+//
+//	(go:new 'net/http.Cookie :Name "a" :Value "b")
+func doGoNew(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for go:new")
+	}
+	typ, err := goType(env, node.car)
+	if err != nil {
+		return nil, err
+	}
+	p := reflect.New(typ)
+	for curr := node.cdr; curr != nil && curr.car != nil; curr = curr.cdr.cdr {
+		s, ok := symbolOf(curr.car)
+		if !ok || !s.IsKeyword() || curr.cdr == nil || curr.cdr.car == nil {
+			return nil, fmt.Errorf("invalid field for go:new: %v", curr.car)
+		}
+		if typ.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%v is not a struct", typ)
+		}
+		fv, ok, err := structField(p.Elem(), s.name[1:])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown field %v of %v", s.name[1:], typ)
+		}
+		v, err := eval(env, curr.cdr.car)
+		if err != nil {
+			return nil, err
+		}
+		rv, err := goArg(env, v, fv.Type())
+		if err != nil {
+			return nil, fmt.Errorf("field %v of %v: %v", s.name[1:], typ, err)
+		}
+		fv.Set(rv)
+	}
+	return &Node{
+		t: NodeGoValue,
+		v: p,
+	}, nil
+}
+
+func doGoZero(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for go:zero")
+	}
+	typ, err := goType(env, node.car)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		t: NodeGoValue,
+		v: reflect.New(typ).Elem(),
+	}, nil
+}
+
+func doGoConvert(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for go:convert")
+	}
+	typ, err := goType(env, node.car)
+	if err != nil {
+		return nil, err
+	}
+	v, err := eval(env, node.cdr.car)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := nodeToValue(v, typ)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		t: NodeGoValue,
+		v: rv,
+	}, nil
+}
+
+// goValueOf returns the Go value wrapped by node, unwrapping a result list
+// holding a single value.
+func goValueOf(node *Node, name string) (reflect.Value, error) {
+	if node != nil && node.t == NodeCell && node.car != nil && node.car.t == NodeGoValue && node.CdrIsNil() {
+		node = node.car
+	}
+	if node == nil || node.t != NodeGoValue {
+		return reflect.Value{}, fmt.Errorf("invalid arguments for %v", name)
+	}
+	rv := node.v.(reflect.Value)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			break
+		}
+		rv = rv.Elem()
+	}
+	return rv, nil
+}
+
+// sortedKeys returns the keys of the map rv in a stable order.
+func sortedKeys(rv reflect.Value) []reflect.Value {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+// doGoToList converts a Go slice or array to a list, and a map to an alist
+// sorted by key.
+func doGoToList(env *Env, node *Node) (*Node, error) {
+	rv, err := goValueOf(node.car, "go:to-list")
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*Node
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			nodes = append(nodes, valueToNode(rv.Index(i)))
+		}
+	case reflect.Map:
+		for _, k := range sortedKeys(rv) {
+			nodes = append(nodes, Cons(valueToNode(k), valueToNode(rv.MapIndex(k))))
+		}
+	default:
+		return nil, fmt.Errorf("can not convert %v to list", rv.Type())
+	}
+	if err := env.alloc(int64(len(nodes))); err != nil {
+		return nil, err
+	}
+	return List(nodes...), nil
+}
+
+// doGoToHash converts a Go map to a hash table.
+func doGoToHash(env *Env, node *Node) (*Node, error) {
+	rv, err := goValueOf(node.car, "go:to-hash")
+	if err != nil {
+		return nil, err
+	}
+	if rv.Kind() != reflect.Map {
+		return nil, fmt.Errorf("can not convert %v to hash table", rv.Type())
+	}
+	h := NewHashTable()
+	for _, k := range sortedKeys(rv) {
+		h.Set(valueToNode(k), valueToNode(rv.MapIndex(k)))
+	}
+	return Hash(h), nil
+}
@@ -0,0 +1,81 @@
+package golisp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGoType(t *testing.T) {
+	env := NewEnv(nil)
+	tests := []struct {
+		src  string
+		want reflect.Type
+	}{
+		{`(go:type int)`, reflect.TypeOf(0)},
+		{`(go:type error)`, errorType},
+		{`(go:type (ptr (slice string)))`, reflect.TypeOf(&[]string{})},
+		{`(go:type (map string (array 2 float64)))`, reflect.TypeOf(map[string][2]float64{})},
+	}
+	for _, test := range tests {
+		ret, err := env.EvalString(context.Background(), test.src)
+		if err != nil {
+			t.Fatalf("%s: %v", test.src, err)
+		}
+		got, ok := typeOf(ret)
+		if !ok || got != test.want {
+			t.Errorf("%s: want %v but got %v", test.src, test.want, ret)
+		}
+	}
+
+	for _, src := range []string{
+		`(go:type net/http.NoSuchType)`,
+		`(go:type (map (slice int) int))`,
+		`(go:type (array -1 int))`,
+		`(go:type 1)`,
+	} {
+		if _, err := env.EvalString(context.Background(), src); err == nil {
+			t.Errorf("%s: want error", src)
+		}
+	}
+}
+
+func TestGoTypeSandbox(t *testing.T) {
+	env := NewEnv(nil)
+	env.SetSandbox(Sandbox{})
+	_, err := env.EvalString(context.Background(), `(go:new net/http.Client)`)
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("want ErrForbidden but got %v", err)
+	}
+}
+
+func TestGoNew(t *testing.T) {
+	env := NewEnv(nil)
+	ret, err := env.EvalString(context.Background(), `(go:new net/http.Cookie :name "a" :value "b" :max-age 10)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, _ := ret.AsGoValue()
+	c, ok := v.(*http.Cookie)
+	if !ok {
+		t.Fatalf("want *http.Cookie but got %T", v)
+	}
+	if c.Name != "a" || c.Value != "b" || c.MaxAge != 10 {
+		t.Fatalf("unexpected cookie: %+v", c)
+	}
+
+	for _, src := range []string{
+		`(go:new net/http.Cookie :NoSuchField 1)`,
+		`(go:new net/http.Cookie :Name 1)`,
+		`(go:new net/http.Cookie Name "a")`,
+		`(go:new net/http.Cookie :Name)`,
+		`(go:new int :Name "a")`,
+		`(go:new int8 :x 1000)`,
+	} {
+		if _, err := env.EvalString(context.Background(), src); err == nil {
+			t.Errorf("%s: want error", src)
+		}
+	}
+}
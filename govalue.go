@@ -0,0 +1,334 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var symGoIndex = intern("go:index")
+
+// indirect follows pointers and interfaces of rv until a nil or a value.
+func indirect(rv reflect.Value) reflect.Value {
+	for (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+// goObject returns the Go value of node for indexing. Strings are
+// accepted as Go strings.
+func goObject(node *Node, name string) (reflect.Value, error) {
+	if node != nil && node.t == NodeString {
+		return reflect.ValueOf(node.v.(string)), nil
+	}
+	rv, err := goValueOf(node, name)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return indirect(rv), nil
+}
+
+// goField returns the field name of the struct obj, following pointers.
+func goField(obj *Node, name string) (reflect.Value, error) {
+	if obj.t == NodeCell && obj.car != nil && obj.car.t == NodeGoValue {
+		obj = obj.car
+	}
+	rv, ok := obj.v.(reflect.Value)
+	if !ok {
+		rv = reflect.ValueOf(obj.v)
+	}
+	rv = indirect(rv)
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("invalid arguments for .: %v", obj)
+	}
+	fv := rv.FieldByName(name)
+	if !fv.IsValid() {
+		return reflect.Value{}, fmt.Errorf("unknown field %v of %v", name, rv.Type())
+	}
+	return fv, nil
+}
+
+// setField sets the field of the place (. obj Field) to value.
+func setField(env *Env, place, value *Node) error {
+	if place.cdr == nil || place.cdr.cdr == nil || place.cdr.cdr.car == nil || place.cdr.cdr.car.t != NodeIdent {
+		return errors.New("invalid arguments for .")
+	}
+	obj, err := eval(env, place.cdr.car)
+	if err != nil {
+		return err
+	}
+	fv, err := goField(obj, place.cdr.cdr.car.v.(*symbol).name)
+	if err != nil {
+		return err
+	}
+	if !fv.CanSet() {
+		return fmt.Errorf("can not set field %v", place.cdr.cdr.car)
+	}
+	rv, err := goArg(env, value, fv.Type())
+	if err != nil {
+		return err
+	}
+	fv.Set(rv)
+	return nil
+}
+
+// index returns the index of the slice, array or string rv for the key
+// node, checking the bounds.
+func index(rv reflect.Value, key *Node, max int) (int, error) {
+	i, ok := key.AsInt()
+	if !ok {
+		return 0, fmt.Errorf("invalid index: %v", key)
+	}
+	if i < 0 || i >= int64(max) {
+		return 0, fmt.Errorf("index out of range: %v with length %v", i, rv.Len())
+	}
+	return int(i), nil
+}
+
+// doGoIndex returns an element of a slice, an array, a string or a map. A
+// missing map key yields nil.
+func doGoIndex(env *Env, node *Node) (rret *Node, rerr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			rerr = errors.New(fmt.Sprint(err))
+		}
+	}()
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for go:index")
+	}
+	rv, err := goObject(node.car, "go:index")
+	if err != nil {
+		return nil, err
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.String:
+		i, err := index(rv, node.cdr.car, rv.Len())
+		if err != nil {
+			return nil, err
+		}
+		return valueToNode(rv.Index(i)), nil
+	case reflect.Map:
+		k, err := goArg(env, node.cdr.car, rv.Type().Key())
+		if err != nil {
+			return nil, err
+		}
+		return valueToNode(rv.MapIndex(k)), nil
+	}
+	return nil, fmt.Errorf("can not index %v", rv.Type())
+}
+
+func doGoSetIndex(env *Env, node *Node) (rret *Node, rerr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			rerr = errors.New(fmt.Sprint(err))
+		}
+	}()
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil || node.cdr.cdr == nil || node.cdr.cdr.car == nil {
+		return nil, errors.New("invalid arguments for go:set-index")
+	}
+	if err := setIndex(env, node.car, node.cdr.car, node.cdr.cdr.car); err != nil {
+		return nil, err
+	}
+	return node.cdr.cdr.car, nil
+}
+
+func setIndex(env *Env, obj, key, value *Node) error {
+	rv, err := goObject(obj, "go:set-index")
+	if err != nil {
+		return err
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		i, err := index(rv, key, rv.Len())
+		if err != nil {
+			return err
+		}
+		ev := rv.Index(i)
+		if !ev.CanSet() {
+			return fmt.Errorf("can not set index of %v", rv.Type())
+		}
+		v, err := goArg(env, value, ev.Type())
+		if err != nil {
+			return err
+		}
+		ev.Set(v)
+		return nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return errors.New("assignment to nil map")
+		}
+		k, err := goArg(env, key, rv.Type().Key())
+		if err != nil {
+			return err
+		}
+		v, err := goArg(env, value, rv.Type().Elem())
+		if err != nil {
+			return err
+		}
+		rv.SetMapIndex(k, v)
+		return nil
+	}
+	return fmt.Errorf("can not index %v", rv.Type())
+}
+
+func doGoLen(env *Env, node *Node) (*Node, error) {
+	rv, err := goObject(node.car, "go:len")
+	if err != nil {
+		return nil, err
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.String, reflect.Map, reflect.Chan:
+		return &Node{
+			t: NodeInt,
+			v: int64(rv.Len()),
+		}, nil
+	}
+	return nil, fmt.Errorf("can not take length of %v", rv.Type())
+}
+
+// doGoSlice returns obj[start:end] of a slice, an array or a string.
+//
+//	(go:slice obj start [end])
+func doGoSlice(env *Env, node *Node) (rret *Node, rerr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			rerr = errors.New(fmt.Sprint(err))
+		}
+	}()
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for go:slice")
+	}
+	rv, err := goObject(node.car, "go:slice")
+	if err != nil {
+		return nil, err
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.String:
+	case reflect.Array:
+		if !rv.CanAddr() {
+			return nil, fmt.Errorf("can not slice unaddressable %v", rv.Type())
+		}
+	default:
+		return nil, fmt.Errorf("can not slice %v", rv.Type())
+	}
+	start, err := index(rv, node.cdr.car, rv.Len()+1)
+	if err != nil {
+		return nil, err
+	}
+	end := rv.Len()
+	if node.cdr.cdr != nil && node.cdr.cdr.car != nil {
+		end, err = index(rv, node.cdr.cdr.car, rv.Len()+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if start > end {
+		return nil, fmt.Errorf("invalid slice indices: %v > %v", start, end)
+	}
+	ret := rv.Slice(start, end)
+	if ret.Kind() == reflect.String {
+		return valueToNode(ret), nil
+	}
+	return &Node{
+		t: NodeGoValue,
+		v: ret,
+	}, nil
+}
+
+// doGoRange evaluates the body for each element of a slice, an array, a
+// string, a map or a channel. A single variable is bound to the element, or
+// the value of a map. With two variables, the first is bound to the index
+// or key. Like range in Go, strings are ranged over their runes, indexed by
+// byte offset, and maps are ranged over in the order of their keys.
+//
+//	(go:range (v obj) body...)
+//	(go:range (k v obj) body...)
+func doGoRange(env *Env, node *Node) (rret *Node, rerr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			rerr = errors.New(fmt.Sprint(err))
+		}
+	}()
+	if node.car == nil || node.car.t != NodeCell {
+		return nil, errors.New("invalid arguments for go:range")
+	}
+	spec := node.car.ToSlice()
+	if len(spec) < 2 || len(spec) > 3 {
+		return nil, errors.New("invalid arguments for go:range")
+	}
+	var vars []*symbol
+	for _, v := range spec[:len(spec)-1] {
+		s, ok := symbolOf(v)
+		if !ok {
+			return nil, errors.New("invalid arguments for go:range")
+		}
+		vars = append(vars, s)
+	}
+	obj, err := eval(env, spec[len(spec)-1])
+	if err != nil {
+		return nil, err
+	}
+	rv, err := goObject(obj, "go:range")
+	if err != nil {
+		return nil, err
+	}
+
+	body := func(k, v *Node) error {
+		scope := NewEnv(env)
+		if len(vars) == 2 {
+			scope.putVar(vars[0], k)
+			scope.putVar(vars[1], v)
+		} else {
+			scope.putVar(vars[0], v)
+		}
+		_, err := evalForms(scope, node.cdr)
+		return err
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		for i, r := range rv.String() {
+			if err := body(Int(int64(i)), valueToNode(reflect.ValueOf(r))); err != nil {
+				return nil, err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := body(Int(int64(i)), valueToNode(rv.Index(i))); err != nil {
+				return nil, err
+			}
+		}
+	case reflect.Map:
+		for _, k := range sortedKeys(rv) {
+			if err := body(valueToNode(k), valueToNode(rv.MapIndex(k))); err != nil {
+				return nil, err
+			}
+		}
+	case reflect.Chan:
+		if len(vars) != 1 {
+			return nil, errors.New("go:range over channel permits only one variable")
+		}
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: rv},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(env.done())},
+		}
+		for {
+			chosen, v, ok := reflect.Select(cases)
+			if chosen == 1 {
+				return nil, env.canceled()
+			}
+			if !ok {
+				break
+			}
+			if err := body(nil, valueToNode(v)); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("can not range over %v", rv.Type())
+	}
+	return &Node{
+		t: NodeNil,
+	}, nil
+}
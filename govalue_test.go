@@ -0,0 +1,48 @@
+package golisp
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+	Tags []string
+}
+
+func TestGoValueErrors(t *testing.T) {
+	env := NewEnv(nil)
+	env.Define("pt", &point{X: 1, Tags: []string{"a"}})
+	env.Define("make-point", func() point { return point{} })
+
+	ret, err := env.EvalString(context.Background(), `(setf (. pt Y) 2) (go:set-index (car (. pt Tags)) 0 "b") (list (. pt Y) (go:index (. pt Tags) 0))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ret.String(); got != `((2) "b")` {
+		t.Fatalf("want ((2) \"b\") but got %v", got)
+	}
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`(go:index (. pt Tags) 5)`, "index out of range"},
+		{`(go:index (. pt Tags) -1)`, "index out of range"},
+		{`(go:index pt 0)`, "can not index"},
+		{`(go:set-index (go:zero (map string int)) "a" 1)`, "nil map"},
+		{`(go:slice (. pt Tags) 2)`, "index out of range"},
+		{`(. pt Z)`, "unknown field"},
+		{`(setf (. pt Z) 1)`, "unknown field"},
+		{`(setf (. (make-point) X) 1)`, "can not set"},
+		{`(setf (. pt X) "a")`, "cannot"},
+		{`(go:range (x 1) x)`, "invalid arguments"},
+	}
+	for _, test := range tests {
+		_, err := env.EvalString(context.Background(), test.src)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: want error containing %q but got %v", test.src, test.want, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package golisp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// HashTable maps keys to values. Numbers, strings, symbols and comparable
+// Go values are compared by value, other keys by identity. Keys are kept
+// in insertion order.
+type HashTable struct {
+	mu    sync.RWMutex
+	index map[interface{}]int
+	keys  []*Node
+	vals  []*Node
+}
+
+type stringKey string
+
+// NewHashTable returns an empty hash table.
+func NewHashTable() *HashTable {
+	return &HashTable{
+		index: make(map[interface{}]int),
+	}
+}
+
+// Hash returns a node holding h.
+func Hash(h *HashTable) *Node {
+	return &Node{
+		t: NodeHash,
+		v: h,
+	}
+}
+
+func hashKey(node *Node) interface{} {
+	if node == nil {
+		return nil
+	}
+	switch node.t {
+	case NodeNil:
+		return nil
+	case NodeT:
+		return true
+	case NodeInt, NodeDouble, NodeIdent:
+		return node.v
+	case NodeString:
+		return stringKey(node.v.(string))
+	case NodeGoValue:
+		rv := node.v.(reflect.Value)
+		if rv.IsValid() && rv.Type().Comparable() && rv.CanInterface() {
+			return rv.Interface()
+		}
+	}
+	return node
+}
+
+// Get returns the value of key.
+func (h *HashTable) Get(key *Node) (*Node, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	i, ok := h.index[hashKey(key)]
+	if !ok {
+		return nil, false
+	}
+	return h.vals[i], true
+}
+
+// Set sets the value of key.
+func (h *HashTable) Set(key, value *Node) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := hashKey(key)
+	if i, ok := h.index[k]; ok {
+		h.vals[i] = value
+		return
+	}
+	h.index[k] = len(h.keys)
+	h.keys = append(h.keys, key)
+	h.vals = append(h.vals, value)
+}
+
+// Delete removes key, and reports whether it was present.
+func (h *HashTable) Delete(key *Node) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := hashKey(key)
+	i, ok := h.index[k]
+	if !ok {
+		return false
+	}
+	delete(h.index, k)
+	h.keys = append(h.keys[:i:i], h.keys[i+1:]...)
+	h.vals = append(h.vals[:i:i], h.vals[i+1:]...)
+	for j := i; j < len(h.keys); j++ {
+		h.index[hashKey(h.keys[j])] = j
+	}
+	return true
+}
+
+func (h *HashTable) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.keys)
+}
+
+// Keys returns the keys in insertion order.
+func (h *HashTable) Keys() []*Node {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]*Node(nil), h.keys...)
+}
+
+// Each calls fn for each entry in insertion order. It stops at the first
+// error and returns it.
+func (h *HashTable) Each(fn func(key, value *Node) error) error {
+	h.mu.RLock()
+	keys := append([]*Node(nil), h.keys...)
+	vals := append([]*Node(nil), h.vals...)
+	h.mu.RUnlock()
+	for i, key := range keys {
+		if err := fn(key, vals[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *HashTable) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("#s(hash-table")
+	h.Each(func(key, value *Node) error {
+		fmt.Fprintf(&buf, " %v %v", key, value)
+		return nil
+	})
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func hashOf(node *Node, name string) (*HashTable, error) {
+	if node == nil || node.t != NodeHash {
+		return nil, fmt.Errorf("invalid arguments for %v", name)
+	}
+	return node.v.(*HashTable), nil
+}
+
+func doMakeHashTable(env *Env, node *Node) (*Node, error) {
+	return Hash(NewHashTable()), nil
+}
+
+// doGethash returns the value of a key, or the default if it is missing.
+//
+//	(gethash key hash [default])
+func doGethash(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil {
+		return nil, errors.New("invalid arguments for gethash")
+	}
+	h, err := hashOf(node.cdr.car, "gethash")
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := h.Get(node.car); ok {
+		return v, nil
+	}
+	if node.cdr.cdr != nil && node.cdr.cdr.car != nil {
+		return node.cdr.cdr.car, nil
+	}
+	return &Node{
+		t: NodeNil,
+	}, nil
+}
+
+// doPuthash sets the value of a key.
+//
+//	(puthash key value hash)
+func doPuthash(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil || node.cdr.cdr == nil {
+		return nil, errors.New("invalid arguments for puthash")
+	}
+	h, err := hashOf(node.cdr.cdr.car, "puthash")
+	if err != nil {
+		return nil, err
+	}
+	h.Set(node.car, node.cdr.car)
+	return node.cdr.car, nil
+}
+
+func doRemhash(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil {
+		return nil, errors.New("invalid arguments for remhash")
+	}
+	h, err := hashOf(node.cdr.car, "remhash")
+	if err != nil {
+		return nil, err
+	}
+	return Bool(h.Delete(node.car)), nil
+}
+
+func doHashTableCount(env *Env, node *Node) (*Node, error) {
+	h, err := hashOf(node.car, "hash-table-count")
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		t: NodeInt,
+		v: int64(h.Len()),
+	}, nil
+}
+
+func doHashTableP(env *Env, node *Node) (*Node, error) {
+	return Bool(node.car != nil && node.car.t == NodeHash), nil
+}
+
+func doHashTableKeys(env *Env, node *Node) (*Node, error) {
+	h, err := hashOf(node.car, "hash-table-keys")
+	if err != nil {
+		return nil, err
+	}
+	return List(h.Keys()...), nil
+}
+
+// doMaphash calls a function with each key and value.
+//
+//	(maphash (lambda (k v) ...) hash)
+func doMaphash(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil {
+		return nil, errors.New("invalid arguments for maphash")
+	}
+	h, err := hashOf(node.cdr.car, "maphash")
+	if err != nil {
+		return nil, err
+	}
+	fn := node.car
+	err = h.Each(func(key, value *Node) error {
+		_, err := eval(env, List(fn, &Node{t: NodeQuote, car: key}, &Node{t: NodeQuote, car: value}))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		t: NodeNil,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package golisp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHashTable(t *testing.T) {
+	h := NewHashTable()
+	h.Set(String("a"), Int(1))
+	h.Set(Keyword("b"), Int(2))
+	h.Set(String("a"), Int(3))
+	if h.Len() != 2 {
+		t.Fatalf("want 2 but got %d", h.Len())
+	}
+	if v, ok := h.Get(String("a")); !ok || v.String() != "3" {
+		t.Fatalf("want 3 but got %v", v)
+	}
+	if v, ok := h.Get(Keyword("b")); !ok || v.String() != "2" {
+		t.Fatalf("want 2 but got %v", v)
+	}
+	if _, ok := h.Get(Symbol("a")); ok {
+		t.Fatal("symbol a should differ from string a")
+	}
+	if !h.Delete(String("a")) || h.Delete(String("a")) {
+		t.Fatal("delete should succeed only once")
+	}
+	if got := List(h.Keys()...).String(); got != "(:b)" {
+		t.Fatalf("want (:b) but got %v", got)
+	}
+}
+
+func TestGoToHash(t *testing.T) {
+	env := NewEnv(nil)
+	env.Define("counts", func() map[string]int {
+		return map[string]int{"b": 2, "a": 1}
+	})
+	env.Define("names", func() []string {
+		return []string{"x", "y"}
+	})
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`(go:to-hash (counts))`, `#s(hash-table "a" 1 "b" 2)`},
+		{`(go:to-list (counts))`, `(("a" . 1) ("b" . 2))`},
+		{`(go:to-list (names))`, `("x" "y")`},
+		{`(gethash "b" (go:to-hash (counts)))`, `2`},
+	}
+	for _, test := range tests {
+		ret, err := env.EvalString(context.Background(), test.src)
+		if err != nil {
+			t.Fatalf("%s: %v", test.src, err)
+		}
+		if got := ret.String(); got != test.want {
+			t.Errorf("%s: want %v but got %v", test.src, test.want, got)
+		}
+	}
+}
+
+func TestHandlerCaseFatal(t *testing.T) {
+	env := NewEnv(nil)
+	env.SetSandbox(Sandbox{MaxSteps: 100})
+	_, err := env.EvalString(context.Background(), `(handler-case (while t) (error (e) "caught"))`)
+	if !errors.Is(err, ErrStepLimit) {
+		t.Fatalf("want ErrStepLimit but got %v", err)
+	}
+
+	env = NewEnv(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = env.EvalString(ctx, `(handler-case (while t) (error (e) "caught"))`)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("want ErrCanceled but got %v", err)
+	}
+}
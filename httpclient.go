@@ -0,0 +1,193 @@
+package golisp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var (
+	kwStatus  = Keyword("status")
+	kwHeaders = Keyword("headers")
+	kwBody    = Keyword("body")
+)
+
+// httpRequestOptions are the keyword options of http-request.
+var httpRequestOptions = []string{"headers", "query", "body", "json", "timeout", "as"}
+
+// doHTTPGet sends a GET request.
+//
+//	(http-get url :headers '(("Accept" . "text/plain")) :query '(("q" . "lisp")))
+func doHTTPGet(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for http-get")
+	}
+	return httpDo(env, "http-get", "GET", node)
+}
+
+// doHTTPPost sends a POST request.
+//
+//	(http-post url :json data)
+func doHTTPPost(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for http-post")
+	}
+	return httpDo(env, "http-post", "POST", node)
+}
+
+// doHTTPRequest sends a request with any method.
+//
+//	(http-request "PUT" url :body "text" :timeout "5s")
+func doHTTPRequest(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil {
+		return nil, errors.New("invalid arguments for http-request")
+	}
+	method, ok := designatorName(node.car)
+	if !ok {
+		return nil, fmt.Errorf("invalid method: %v", node.car)
+	}
+	return httpDo(env, "http-request", strings.ToUpper(method), node.cdr)
+}
+
+// httpDo sends a request to the url in node with the options following
+// it. The response is a hash table of :status, :headers and :body. The
+// body is decoded if it is JSON, unless :as is :text.
+func httpDo(env *Env, name, method string, node *Node) (*Node, error) {
+	args, opts, err := keywordArgs(node, name, httpRequestOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("invalid arguments for %v", name)
+	}
+	rawurl, ok := args[0].AsString()
+	if !ok {
+		return nil, fmt.Errorf("invalid url: %v", args[0])
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := opts["query"]; ok {
+		q := u.Query()
+		if err := eachValue(v, q.Add); err != nil {
+			return nil, err
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	var body io.Reader
+	header := http.Header{}
+	if v, ok := opts["body"]; ok {
+		s, ok := v.AsString()
+		if !ok {
+			return nil, fmt.Errorf("invalid body: %v", v)
+		}
+		body = strings.NewReader(s)
+	}
+	if v, ok := opts["json"]; ok {
+		if body != nil {
+			return nil, fmt.Errorf("both :body and :json given to %v", name)
+		}
+		var buf bytes.Buffer
+		if err := encodeJSON(&buf, v); err != nil {
+			return nil, err
+		}
+		body = &buf
+		header.Set("Content-Type", "application/json")
+	}
+	if v, ok := opts["headers"]; ok {
+		// Given headers replace those set for :json, and keep all of
+		// their values.
+		h := http.Header{}
+		if err := eachValue(v, h.Add); err != nil {
+			return nil, err
+		}
+		for k, vs := range h {
+			header[k] = vs
+		}
+	}
+	as := ""
+	if v, ok := opts["as"]; ok {
+		as, _ = designatorName(v)
+		if as != "text" && as != "json" {
+			return nil, fmt.Errorf("invalid value for :as: %v", v)
+		}
+	}
+
+	ctx := env.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if v, ok := opts["timeout"]; ok {
+		d, err := duration(v)
+		if err != nil {
+			return nil, err
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if cerr := env.canceled(); cerr != nil {
+			return nil, cerr
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(resp.Header))
+	for k := range resp.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	headers := NewHashTable()
+	for _, k := range keys {
+		vs := resp.Header[k]
+		if len(vs) == 1 {
+			headers.Set(String(k), String(vs[0]))
+			continue
+		}
+		items := make([]*Node, len(vs))
+		for i, v := range vs {
+			items[i] = String(v)
+		}
+		headers.Set(String(k), List(items...))
+	}
+	ret := NewHashTable()
+	ret.Set(kwStatus, Int(int64(resp.StatusCode)))
+	ret.Set(kwHeaders, Hash(headers))
+	if as == "" {
+		as = "text"
+		if mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json")) {
+			as = "json"
+		}
+	}
+	if as == "json" && len(b) > 0 {
+		v, err := decodeJSON(bytes.NewReader(b), jsonOptions{})
+		if err != nil {
+			return nil, err
+		}
+		ret.Set(kwBody, v)
+	} else {
+		ret.Set(kwBody, String(string(b)))
+	}
+	return Hash(ret), nil
+}
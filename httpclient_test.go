@@ -0,0 +1,129 @@
+package golisp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		case "/text":
+			w.Header().Add("X-Multi", "a")
+			w.Header().Add("X-Multi", "b")
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, `{"not":"decoded"}`)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"method":       r.Method,
+			"query":        r.URL.RawQuery,
+			"token":        r.Header.Get("X-Token"),
+			"accept":       strings.Join(r.Header.Values("Accept"), ","),
+			"content-type": r.Header.Get("Content-Type"),
+			"body":         string(body),
+		})
+	}))
+	defer srv.Close()
+
+	env := NewEnv(nil)
+	env.Define("url", srv.URL)
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{
+			input: `(gethash :status (http-get url :timeout "1s"))`,
+			want:  `200`,
+		},
+		{
+			input: `(gethash "query" (gethash :body (http-get url :query '(("q" . "lisp & go") ("n" . 1)) :headers '(("X-Token" . "secret")))))`,
+			want:  `"n=1&q=lisp+%26+go"`,
+		},
+		{
+			input: `(gethash "token" (gethash :body (http-get (concatenate 'string url "?a=b") :headers '(("X-Token" . "secret")))))`,
+			want:  `"secret"`,
+		},
+		{
+			input: `(let ((b (gethash :body (http-post url :json '(1 "ü" 12345678901234567))))) (list (gethash "method" b) (gethash "content-type" b) (gethash "body" b)))`,
+			want:  `("POST" "application/json" "[1,\"ü\",12345678901234567]")`,
+		},
+		{
+			input: `(gethash "accept" (gethash :body (http-get url :headers '(("Accept" "text/plain" "application/json")))))`,
+			want:  `"text/plain,application/json"`,
+		},
+		{
+			input: `(gethash "content-type" (gethash :body (http-post url :json '(1) :headers '(("Content-Type" . "application/vnd.api+json")))))`,
+			want:  `"application/vnd.api+json"`,
+		},
+		{
+			input: `(gethash "body" (gethash :body (http-request 'put url :body "raw")))`,
+			want:  `"raw"`,
+		},
+		{
+			input: `(gethash "method" (gethash :body (http-request "delete" url)))`,
+			want:  `"DELETE"`,
+		},
+		{
+			input: `(gethash :body (http-get url :as :text))`,
+			want:  `"{\"accept\":\"\",\"body\":\"\",\"content-type\":\"\",\"method\":\"GET\",\"query\":\"\",\"token\":\"\"}\n"`,
+		},
+		{
+			input: `(let ((r (http-get (concatenate 'string url "/text")))) (list (gethash :status r) (gethash "X-Multi" (gethash :headers r)) (gethash :body r)))`,
+			want:  `(418 ("a" "b") "{\"not\":\"decoded\"}")`,
+		},
+		{
+			input: `(gethash :body (http-get (concatenate 'string url "/text") :as :json))`,
+			want:  `#s(hash-table "not" "decoded")`,
+		},
+	}
+	for _, test := range tests {
+		got, err := env.EvalString(context.Background(), test.input)
+		if err != nil {
+			t.Fatalf("%v: %v", test.input, err)
+		}
+		if got.String() != test.want {
+			t.Errorf("want %v for %v but got %v", test.want, test.input, got)
+		}
+	}
+
+	for _, src := range []string{
+		`(http-get (concatenate 'string url "/slow") :timeout "50ms")`,
+		`(http-get url :method "PUT")`,
+		`(http-get url :as :xml)`,
+		`(http-post url :body "a" :json "b")`,
+		`(http-get 1)`,
+		`(http-get "http://[::1")`,
+	} {
+		if _, err := env.EvalString(context.Background(), src); err == nil {
+			t.Errorf("%s: want error", src)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := env.EvalString(ctx, `(http-get (concatenate 'string url "/slow"))`)
+	if !errors.Is(err, ErrCanceled) {
+		t.Errorf("want ErrCanceled but got %v", err)
+	}
+
+	env.SetSandbox(Sandbox{})
+	if _, err := env.EvalString(context.Background(), `(http-get url)`); err == nil || !strings.Contains(err.Error(), "http-get") {
+		t.Errorf("want error for http-get in sandbox but got %v", err)
+	}
+}
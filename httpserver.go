@@ -0,0 +1,575 @@
+package golisp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type route struct {
+	method  string
+	pattern []string
+	handler *Node
+}
+
+// router dispatches HTTP requests to the handlers defined with defroute.
+// Handlers are evaluated in their own scope with the context of the
+// request, so requests are served concurrently and stop when they are
+// canceled.
+type router struct {
+	env        *Env
+	mu         sync.RWMutex
+	routes     []*route
+	middleware []*Node
+}
+
+// httpRequest is the request passed to handlers.
+type httpRequest struct {
+	r      *http.Request
+	params map[string]string
+	once   sync.Once
+	body   []byte
+	err    error
+}
+
+// httpResponse is the response made by response and json-response.
+type httpResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// router returns the router of the interpreter of e.
+func (e *Env) router() *router {
+	root := e.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if root.routes == nil {
+		root.routes = &router{env: root}
+	}
+	return root.routes
+}
+
+// HTTPHandler returns the handler serving the routes defined with defroute
+// in the interpreter of e.
+func (e *Env) HTTPHandler() http.Handler {
+	return e.router()
+}
+
+func splitPath(p string) []string {
+	return strings.FieldsFunc(p, func(r rune) bool { return r == '/' })
+}
+
+// match reports whether path matches the pattern of rt, and returns the
+// values of its :name segments. A * segment matches the rest of the path.
+func (rt *route) match(path []string) (map[string]string, bool) {
+	params := map[string]string{}
+	for i, seg := range rt.pattern {
+		if seg == "*" {
+			params["*"] = strings.Join(path[i:], "/")
+			return params, true
+		}
+		if i >= len(path) {
+			return nil, false
+		}
+		if strings.HasPrefix(seg, ":") {
+			params[seg[1:]] = path[i]
+		} else if seg != path[i] {
+			return nil, false
+		}
+	}
+	return params, len(path) == len(rt.pattern)
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := splitPath(r.URL.Path)
+	rt.mu.RLock()
+	var found *route
+	var params map[string]string
+	var allowed []string
+	for _, route := range rt.routes {
+		p, ok := route.match(path)
+		if !ok {
+			continue
+		}
+		if route.method == "*" || route.method == r.Method {
+			found, params = route, p
+			break
+		}
+		allowed = append(allowed, route.method)
+	}
+	middleware := rt.middleware
+	rt.mu.RUnlock()
+
+	if found == nil {
+		if len(allowed) > 0 {
+			sort.Strings(allowed)
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	scope := NewEnv(rt.env)
+	cancel := rt.env.limitScope(scope, r.Context())
+	defer cancel()
+	handler := found.handler
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h, err := eval(scope, List(middleware[i], &Node{t: NodeQuote, car: handler}))
+		if err != nil {
+			rt.fail(w, err)
+			return
+		}
+		handler = h
+	}
+	req := &httpRequest{r: r, params: params}
+	ret, err := eval(scope, List(handler, &Node{t: NodeQuote, car: GoValue(req)}))
+	if err != nil {
+		rt.fail(w, err)
+		return
+	}
+	resp, err := toResponse(ret)
+	if err != nil {
+		rt.fail(w, err)
+		return
+	}
+	for k, vs := range resp.header {
+		w.Header()[k] = vs
+	}
+	w.WriteHeader(resp.status)
+	w.Write(resp.body)
+}
+
+// fail responds with 500 and reports err to the error handler of the
+// interpreter.
+func (rt *router) fail(w http.ResponseWriter, err error) {
+	rt.env.goError(err)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// toResponse converts the value returned by a handler to a response. A
+// string is sent as text, nil as 204, and hash tables, lists and vectors as
+// JSON.
+func toResponse(node *Node) (*httpResponse, error) {
+	if node == nil {
+		node = Nil()
+	}
+	switch node.t {
+	case NodeGoValue:
+		v, _ := node.AsGoValue()
+		if resp, ok := v.(*httpResponse); ok {
+			return resp, nil
+		}
+	case NodeNil:
+		return &httpResponse{status: http.StatusNoContent, header: http.Header{}}, nil
+	case NodeString:
+		return textResponse(node.v.(string)), nil
+	case NodeHash, NodeCell, NodeVector:
+		return jsonResponse(node)
+	}
+	return nil, fmt.Errorf("invalid response: %v", node)
+}
+
+func textResponse(body string) *httpResponse {
+	return &httpResponse{
+		status: http.StatusOK,
+		header: http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+		body:   []byte(body),
+	}
+}
+
+func jsonResponse(node *Node) (*httpResponse, error) {
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, node); err != nil {
+		return nil, err
+	}
+	return &httpResponse{
+		status: http.StatusOK,
+		header: http.Header{"Content-Type": {"application/json"}},
+		body:   buf.Bytes(),
+	}, nil
+}
+
+// keywordArgs splits args into the leading arguments and the values of the
+// keyword options following them. Options not in names are rejected.
+func keywordArgs(args *Node, name string, names ...string) ([]*Node, map[string]*Node, error) {
+	var pos []*Node
+	opts := map[string]*Node{}
+	curr := args
+	for ; curr != nil && curr.car != nil; curr = curr.cdr {
+		if s, ok := symbolOf(curr.car); ok && s.IsKeyword() {
+			break
+		}
+		pos = append(pos, curr.car)
+	}
+	for ; curr != nil && curr.car != nil; curr = curr.cdr.cdr {
+		s, ok := symbolOf(curr.car)
+		if !ok || !s.IsKeyword() || curr.cdr == nil || curr.cdr.car == nil {
+			return nil, nil, fmt.Errorf("invalid option for %v: %v", name, curr.car)
+		}
+		known := false
+		for _, n := range names {
+			known = known || n == s.name[1:]
+		}
+		if !known {
+			return nil, nil, fmt.Errorf("unknown option for %v: %v", name, curr.car)
+		}
+		opts[s.name[1:]] = curr.cdr.car
+	}
+	return pos, opts, nil
+}
+
+// headerOf adds the headers in node, a hash table or an alist of names and
+// values, to h. A value may be a list of strings.
+func headerOf(node *Node, h http.Header) error {
+	return eachValue(node, h.Add)
+}
+
+// eachValue calls add with each name and value in node, a hash table or an
+// alist. A value may be a list, and values other than strings are
+// formatted.
+func eachValue(node *Node, add func(name, value string)) error {
+	pair := func(key, value *Node) error {
+		name, ok := designatorName(key)
+		if !ok {
+			return fmt.Errorf("invalid name: %v", key)
+		}
+		values := []*Node{value}
+		if value.t == NodeCell {
+			values = value.ToSlice()
+		}
+		for _, v := range values {
+			s, ok := v.AsString()
+			if !ok {
+				s = fmt.Sprint(v)
+			}
+			add(name, s)
+		}
+		return nil
+	}
+	switch node.t {
+	case NodeNil:
+		return nil
+	case NodeHash:
+		return node.v.(*HashTable).Each(pair)
+	case NodeCell:
+		for _, item := range node.ToSlice() {
+			if item.t != NodeCell || item.car == nil {
+				return fmt.Errorf("invalid pair: %v", item)
+			}
+			value := item.cdr
+			if value != nil && value.t == NodeCell && value.CdrIsNil() {
+				value = value.car
+			}
+			if value == nil {
+				value = Nil()
+			}
+			if err := pair(item.car, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid hash table or alist: %v", node)
+}
+
+func requestOf(node *Node, name string) (*httpRequest, error) {
+	if node != nil && node.t == NodeGoValue {
+		v, _ := node.AsGoValue()
+		if req, ok := v.(*httpRequest); ok {
+			return req, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid arguments for %v", name)
+}
+
+// doDefroute defines the handler of the requests with a method and a path
+// matching a pattern. :name segments of the pattern match any segment, and
+// a * segment the rest of the path. The method ANY matches every method.
+//
+//	(defroute GET "/users/:id" (req) body...)
+func doDefroute(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil || node.cdr.cdr == nil || node.cdr.cdr.car == nil {
+		return nil, errors.New("invalid arguments for defroute")
+	}
+	method, ok := designatorName(node.car)
+	if !ok {
+		return nil, fmt.Errorf("invalid method for defroute: %v", node.car)
+	}
+	method = strings.ToUpper(method)
+	if method == "ANY" {
+		method = "*"
+	}
+	pattern, err := eval(env, node.cdr.car)
+	if err != nil {
+		return nil, err
+	}
+	if pattern.t != NodeString {
+		return nil, fmt.Errorf("invalid pattern for defroute: %v", pattern)
+	}
+	handler, err := doLambda(env, node.cdr.cdr)
+	if err != nil {
+		return nil, err
+	}
+	rt := env.router()
+	rt.add(&route{
+		method:  method,
+		pattern: splitPath(pattern.v.(string)),
+		handler: handler,
+	})
+	return pattern, nil
+}
+
+// add adds r, replacing the route of the same method and pattern so that
+// a route can be redefined like a function.
+func (rt *router) add(r *route) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	for i, old := range rt.routes {
+		if old.method == r.method && strings.Join(old.pattern, "/") == strings.Join(r.pattern, "/") {
+			rt.routes[i] = r
+			return
+		}
+	}
+	rt.routes = append(rt.routes, r)
+}
+
+// doUseMiddleware adds a middleware, a function taking the next handler and
+// returning a handler. Middleware added first runs first.
+//
+//	(use-middleware (lambda (next) (lambda (req) (funcall next req))))
+func doUseMiddleware(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || !isFunction(env, node.car) {
+		return nil, errors.New("invalid arguments for use-middleware")
+	}
+	rt := env.router()
+	rt.mu.Lock()
+	rt.middleware = append(rt.middleware, node.car)
+	rt.mu.Unlock()
+	return node.car, nil
+}
+
+func doHTTPHandler(env *Env, node *Node) (*Node, error) {
+	return GoValue(http.Handler(env.router())), nil
+}
+
+// doServe serves the routes until the evaluation is canceled.
+//
+//	(serve :addr ":8080")
+func doServe(env *Env, node *Node) (*Node, error) {
+	_, opts, err := keywordArgs(node, "serve", "addr")
+	if err != nil {
+		return nil, err
+	}
+	addr := ":8080"
+	if v, ok := opts["addr"]; ok {
+		if addr, ok = v.AsString(); !ok {
+			return nil, fmt.Errorf("invalid address: %v", v)
+		}
+	}
+	// Requests are canceled with the evaluation, so that Shutdown does not
+	// wait for running handlers.
+	srv := &http.Server{
+		Addr:        addr,
+		Handler:     env.router(),
+		BaseContext: func(net.Listener) context.Context { return env.ctx },
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		return nil, err
+	case <-env.done():
+		srv.Shutdown(context.Background())
+		<-errc
+		return nil, env.canceled()
+	}
+}
+
+func doReqMethod(env *Env, node *Node) (*Node, error) {
+	req, err := requestOf(node.car, "req-method")
+	if err != nil {
+		return nil, err
+	}
+	return String(req.r.Method), nil
+}
+
+func doReqPath(env *Env, node *Node) (*Node, error) {
+	req, err := requestOf(node.car, "req-path")
+	if err != nil {
+		return nil, err
+	}
+	return String(req.r.URL.Path), nil
+}
+
+// doReqParam returns a segment of the path matched by :name in the pattern
+// of the route, or nil.
+//
+//	(req-param req "id")
+func doReqParam(env *Env, node *Node) (*Node, error) {
+	req, err := requestOf(node.car, "req-param")
+	if err != nil {
+		return nil, err
+	}
+	if node.cdr == nil {
+		return nil, errors.New("invalid arguments for req-param")
+	}
+	name, ok := designatorName(node.cdr.car)
+	if !ok {
+		return nil, errors.New("invalid arguments for req-param")
+	}
+	if v, ok := req.params[name]; ok {
+		return String(v), nil
+	}
+	return Nil(), nil
+}
+
+// doReqQuery returns the first value of a query parameter, or nil.
+func doReqQuery(env *Env, node *Node) (*Node, error) {
+	req, err := requestOf(node.car, "req-query")
+	if err != nil {
+		return nil, err
+	}
+	if node.cdr == nil {
+		return nil, errors.New("invalid arguments for req-query")
+	}
+	name, ok := designatorName(node.cdr.car)
+	if !ok {
+		return nil, errors.New("invalid arguments for req-query")
+	}
+	q := req.r.URL.Query()
+	if _, ok := q[name]; !ok {
+		return Nil(), nil
+	}
+	return String(q.Get(name)), nil
+}
+
+// doReqHeader returns the first value of a request header, or nil.
+func doReqHeader(env *Env, node *Node) (*Node, error) {
+	req, err := requestOf(node.car, "req-header")
+	if err != nil {
+		return nil, err
+	}
+	if node.cdr == nil {
+		return nil, errors.New("invalid arguments for req-header")
+	}
+	name, ok := designatorName(node.cdr.car)
+	if !ok {
+		return nil, errors.New("invalid arguments for req-header")
+	}
+	if vs := req.r.Header.Values(name); len(vs) > 0 {
+		return String(vs[0]), nil
+	}
+	return Nil(), nil
+}
+
+func (req *httpRequest) readBody() ([]byte, error) {
+	req.once.Do(func() {
+		req.body, req.err = io.ReadAll(req.r.Body)
+	})
+	return req.body, req.err
+}
+
+func doReqBody(env *Env, node *Node) (*Node, error) {
+	req, err := requestOf(node.car, "req-body")
+	if err != nil {
+		return nil, err
+	}
+	body, err := req.readBody()
+	if err != nil {
+		return nil, err
+	}
+	return String(string(body)), nil
+}
+
+// doReqJSON returns the request body decoded from JSON.
+func doReqJSON(env *Env, node *Node) (*Node, error) {
+	req, err := requestOf(node.car, "req-json")
+	if err != nil {
+		return nil, err
+	}
+	body, err := req.readBody()
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(bytes.NewReader(body), jsonOptions{})
+}
+
+func responseOptions(resp *httpResponse, opts map[string]*Node) error {
+	if v, ok := opts["status"]; ok {
+		status, ok := v.AsInt()
+		if !ok || status < 100 || status > 999 {
+			return fmt.Errorf("invalid status: %v", v)
+		}
+		resp.status = int(status)
+	}
+	if v, ok := opts["content-type"]; ok {
+		s, ok := v.AsString()
+		if !ok {
+			return fmt.Errorf("invalid content type: %v", v)
+		}
+		resp.header.Set("Content-Type", s)
+	}
+	if v, ok := opts["headers"]; ok {
+		return headerOf(v, resp.header)
+	}
+	return nil
+}
+
+// doResponse makes a response with a text body.
+//
+//	(response "created" :status 201 :headers '(("Location" . "/users/1")))
+func doResponse(env *Env, node *Node) (*Node, error) {
+	args, opts, err := keywordArgs(node, "response", "status", "headers", "content-type")
+	if err != nil {
+		return nil, err
+	}
+	body := ""
+	if len(args) > 1 {
+		return nil, errors.New("invalid arguments for response")
+	}
+	if len(args) == 1 && args[0].t != NodeNil {
+		s, ok := args[0].AsString()
+		if !ok {
+			return nil, fmt.Errorf("invalid body: %v", args[0])
+		}
+		body = s
+	}
+	resp := textResponse(body)
+	if err := responseOptions(resp, opts); err != nil {
+		return nil, err
+	}
+	return GoValue(resp), nil
+}
+
+// doJSONResponse makes a response with data encoded as JSON.
+//
+//	(json-response data :status 201)
+func doJSONResponse(env *Env, node *Node) (*Node, error) {
+	args, opts, err := keywordArgs(node, "json-response", "status", "headers")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, errors.New("invalid arguments for json-response")
+	}
+	resp, err := jsonResponse(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := responseOptions(resp, opts); err != nil {
+		return nil, err
+	}
+	return GoValue(resp), nil
+}
@@ -0,0 +1,193 @@
+package golisp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serveTest(t *testing.T, h http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHTTPServer(t *testing.T) {
+	env := NewEnv(nil)
+	if err := LoadLib(env); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	env.SetOut(&out)
+	var handled error
+	env.SetGoErrorHandler(func(err error) { handled = err })
+	_, err := env.EvalString(context.Background(), `
+(require 'http/server)
+(use-middleware 'http:log-requests)
+(defroute GET "/users/:id" (req)
+  (let ((h (make-hash-table)))
+    (puthash "id" (req-param req "id") h)
+    (puthash "q" (req-query req "q") h)
+    (puthash "agent" (req-header req "User-Agent") h)
+    h))
+(defroute POST "/users" (req)
+  (let ((u (req-json req)))
+    (puthash "id" 1 u)
+    (json-response u :status 201 :headers '(("Location" . "/users/1")))))
+(defroute PUT "/echo" (req)
+  (response (req-body req) :content-type "text/csv" :headers '(("X-A" "1" "2"))))
+(defroute GET "/text" (req) "old")
+(defroute GET "/text" (req) "hello")
+(defroute DELETE "/users/:id" (req) nil)
+(defroute ANY "/files/*" (req) (req-param req "*"))
+(defroute GET "/redirect" (req) (http:redirect "/text"))
+(defroute GET "/fail" (req) (error "boom"))
+(defroute GET "/bad" (req) 42)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.EvalString(context.Background(), `(ok "x")`); err == nil {
+		t.Error("want ok to be only in the http package")
+	}
+	h := env.HTTPHandler()
+
+	tests := []struct {
+		method, target, body string
+		header               http.Header
+		status               int
+		want                 string
+		wantHeader           http.Header
+	}{
+		{method: "GET", target: "/users/42?q=x", header: http.Header{"User-Agent": {"test"}}, status: 200, want: `{"id":"42","q":"x","agent":"test"}`, wantHeader: http.Header{"Content-Type": {"application/json"}}},
+		{method: "GET", target: "/users/42", status: 200, want: `{"id":"42","q":null,"agent":null}`},
+		{method: "POST", target: "/users", body: `{"name":"ä","tags":["a",1,2.5,true,false,null]}`, status: 201, want: `{"name":"ä","tags":["a",1,2.5,true,false,null],"id":1}`, wantHeader: http.Header{"Location": {"/users/1"}}},
+		{method: "PUT", target: "/echo", body: "a,b", status: 200, want: "a,b", wantHeader: http.Header{"Content-Type": {"text/csv"}, "X-A": {"1", "2"}}},
+		{method: "GET", target: "/text", status: 200, want: "hello", wantHeader: http.Header{"Content-Type": {"text/plain; charset=utf-8"}}},
+		{method: "DELETE", target: "/users/1", status: 204},
+		{method: "PATCH", target: "/files/a/b.txt", status: 200, want: "a/b.txt"},
+		{method: "GET", target: "/redirect", status: 302, wantHeader: http.Header{"Location": {"/text"}}},
+		{method: "GET", target: "/nothing", status: 404, want: "404 page not found\n"},
+		{method: "POST", target: "/users/1", status: 405, want: "Method Not Allowed\n", wantHeader: http.Header{"Allow": {"DELETE, GET"}}},
+		{method: "POST", target: "/users", body: "{", status: 500, want: "Internal Server Error\n"},
+		{method: "GET", target: "/bad", status: 500},
+	}
+	for _, test := range tests {
+		w := serveTest(t, h, test.method, test.target, test.body, test.header)
+		if w.Code != test.status {
+			t.Errorf("%s %s: want status %d but got %d: %s", test.method, test.target, test.status, w.Code, w.Body)
+			continue
+		}
+		if test.want != "" && w.Body.String() != test.want {
+			t.Errorf("%s %s: want %q but got %q", test.method, test.target, test.want, w.Body)
+		}
+		for k, vs := range test.wantHeader {
+			if got := w.Header().Values(k); strings.Join(got, ",") != strings.Join(vs, ",") {
+				t.Errorf("%s %s: want header %s %q but got %q", test.method, test.target, k, vs, got)
+			}
+		}
+	}
+
+	w := serveTest(t, h, "GET", "/fail", "", nil)
+	if w.Code != 500 || handled == nil || handled.Error() != "boom" {
+		t.Errorf("want 500 and error boom but got %d and %v", w.Code, handled)
+	}
+	if !strings.Contains(out.String(), "GET /users/42") {
+		t.Errorf("want request log but got %q", out.String())
+	}
+}
+
+func TestHTTPServerMiddleware(t *testing.T) {
+	env := NewEnv(nil)
+	if err := LoadLib(env); err != nil {
+		t.Fatal(err)
+	}
+	_, err := env.EvalString(context.Background(), `
+(require 'http/server)
+(use-middleware (http:require-header "X-Token"))
+(use-middleware (lambda (next) (lambda (req) (concatenate 'string "<" (funcall next req) ">"))))
+(defroute GET "/" (req) (req-header req "X-Token"))
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(env.HTTPHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 401 {
+		t.Errorf("want 401 but got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("X-Token", "secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	resp.Body.Close()
+	if buf.String() != "<secret>" {
+		t.Errorf("want %q but got %q", "<secret>", buf.String())
+	}
+}
+
+func TestHTTPServerCancel(t *testing.T) {
+	env := NewEnv(nil)
+	errc := make(chan error, 1)
+	env.SetGoErrorHandler(func(err error) { errc <- err })
+	if _, err := env.EvalString(context.Background(), `(defroute GET "/spin" (req) (while t))`); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/spin", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		env.HTTPHandler().ServeHTTP(w, req)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler was not canceled")
+	}
+	if err := <-errc; !errors.Is(err, ErrCanceled) {
+		t.Fatalf("want ErrCanceled but got %v", err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	env := NewEnv(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := env.EvalString(ctx, `(defroute GET "/" (req) "ok") (serve :addr "127.0.0.1:0")`)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("want ErrCanceled but got %v", err)
+	}
+	if _, err := env.EvalString(context.Background(), `(serve :port 80)`); err == nil {
+		t.Fatal("want error for unknown option")
+	}
+
+	env.SetSandbox(Sandbox{})
+	if _, err := env.EvalString(context.Background(), `(serve)`); err == nil {
+		t.Fatal("want error for serve in sandbox")
+	}
+}
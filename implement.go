@@ -0,0 +1,170 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Adapter builds a value implementing an interface from funcs, which holds
+// a func of the type of each method of the interface keyed by the method
+// name.
+type Adapter func(funcs map[string]reflect.Value) interface{}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+type stringerFunc func() string
+
+func (f stringerFunc) String() string { return f() }
+
+type errorFunc func() string
+
+func (f errorFunc) Error() string { return f() }
+
+type sortAdapter struct {
+	len  func() int
+	less func(int, int) bool
+	swap func(int, int)
+}
+
+func (s *sortAdapter) Len() int           { return s.len() }
+func (s *sortAdapter) Less(i, j int) bool { return s.less(i, j) }
+func (s *sortAdapter) Swap(i, j int)      { s.swap(i, j) }
+
+// adapters are the adapters of the interfaces of the standard library.
+var adapters = map[reflect.Type]Adapter{
+	reflect.TypeOf((*io.Reader)(nil)).Elem(): func(funcs map[string]reflect.Value) interface{} {
+		return readerFunc(funcs["Read"].Interface().(func([]byte) (int, error)))
+	},
+	reflect.TypeOf((*io.Writer)(nil)).Elem(): func(funcs map[string]reflect.Value) interface{} {
+		return writerFunc(funcs["Write"].Interface().(func([]byte) (int, error)))
+	},
+	reflect.TypeOf((*io.Closer)(nil)).Elem(): func(funcs map[string]reflect.Value) interface{} {
+		return closerFunc(funcs["Close"].Interface().(func() error))
+	},
+	reflect.TypeOf((*fmt.Stringer)(nil)).Elem(): func(funcs map[string]reflect.Value) interface{} {
+		return stringerFunc(funcs["String"].Interface().(func() string))
+	},
+	errorType: func(funcs map[string]reflect.Value) interface{} {
+		return errorFunc(funcs["Error"].Interface().(func() string))
+	},
+	reflect.TypeOf((*http.Handler)(nil)).Elem(): func(funcs map[string]reflect.Value) interface{} {
+		return http.HandlerFunc(funcs["ServeHTTP"].Interface().(func(http.ResponseWriter, *http.Request)))
+	},
+	reflect.TypeOf((*sort.Interface)(nil)).Elem(): func(funcs map[string]reflect.Value) interface{} {
+		return &sortAdapter{
+			len:  funcs["Len"].Interface().(func() int),
+			less: funcs["Less"].Interface().(func(int, int) bool),
+			swap: funcs["Swap"].Interface().(func(int, int)),
+		}
+	},
+}
+
+// RegisterInterface registers the adapter used by go:implement for the
+// interface iface in the interpreter of e. iface is a nil pointer to the
+// interface such as (*io.Reader)(nil).
+func (e *Env) RegisterInterface(iface interface{}, adapter Adapter) error {
+	typ := reflect.TypeOf(iface)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf("invalid interface: %T", iface)
+	}
+	root := e.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if root.adapters == nil {
+		root.adapters = make(map[reflect.Type]Adapter)
+	}
+	root.adapters[typ.Elem()] = adapter
+	return nil
+}
+
+func (e *Env) adapter(typ reflect.Type) (Adapter, bool) {
+	root := e.root()
+	root.mu.RLock()
+	adapter, ok := root.adapters[typ]
+	root.mu.RUnlock()
+	if ok {
+		return adapter, true
+	}
+	adapter, ok = adapters[typ]
+	return adapter, ok
+}
+
+// methodOf returns the method of the interface typ named by the keyword
+// name, either as the method name or in kebab-case.
+func methodOf(typ reflect.Type, name string) (reflect.Method, bool) {
+	for i := 0; i < typ.NumMethod(); i++ {
+		m := typ.Method(i)
+		if strings.EqualFold(m.Name, name) || strings.EqualFold(kebabCase(m.Name), name) {
+			return m, true
+		}
+	}
+	return reflect.Method{}, false
+}
+
+// doGoImplement returns a value implementing the interface type with Lisp
+// functions for its methods. The interface must have an adapter.
+//
+//	(go:implement 'net/http.Handler :ServeHTTP (lambda (w r) ...))
+func doGoImplement(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for go:implement")
+	}
+	typ, err := goType(env, node.car)
+	if err != nil {
+		return nil, err
+	}
+	if typ.Kind() != reflect.Interface {
+		return nil, fmt.Errorf("%v is not an interface", typ)
+	}
+	adapter, ok := env.adapter(typ)
+	if !ok {
+		return nil, fmt.Errorf("can not implement %v", typ)
+	}
+	funcs := make(map[string]reflect.Value, typ.NumMethod())
+	for curr := node.cdr; curr != nil && curr.car != nil; curr = curr.cdr.cdr {
+		s, ok := symbolOf(curr.car)
+		if !ok || !s.IsKeyword() || curr.cdr == nil || curr.cdr.car == nil {
+			return nil, fmt.Errorf("invalid method for go:implement: %v", curr.car)
+		}
+		m, ok := methodOf(typ, s.name[1:])
+		if !ok {
+			return nil, fmt.Errorf("unknown method %v of %v", s.name[1:], typ)
+		}
+		fn, err := eval(env, curr.cdr.car)
+		if err != nil {
+			return nil, err
+		}
+		if !isFunction(env, fn) {
+			return nil, fmt.Errorf("method %v of %v: not a function: %v", m.Name, typ, fn)
+		}
+		funcs[m.Name] = makeFunc(env, fn, m.Type)
+	}
+	for i := 0; i < typ.NumMethod(); i++ {
+		if _, ok := funcs[typ.Method(i).Name]; !ok {
+			return nil, fmt.Errorf("missing method %v of %v", typ.Method(i).Name, typ)
+		}
+	}
+	v := reflect.ValueOf(adapter(funcs))
+	if !v.IsValid() || !v.Type().Implements(typ) {
+		return nil, fmt.Errorf("adapter for %v does not implement it", typ)
+	}
+	return &Node{
+		t: NodeGoValue,
+		v: v,
+	}, nil
+}
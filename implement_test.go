@@ -0,0 +1,92 @@
+package golisp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type greeter interface {
+	Greet(name string) string
+}
+
+type greeterFunc func(string) string
+
+func (f greeterFunc) Greet(name string) string { return f(name) }
+
+func TestGoImplementHandler(t *testing.T) {
+	env := NewEnv(nil)
+	ret, err := env.EvalString(context.Background(), `
+(go:implement 'net/http.Handler
+  :serve-http (lambda (w r)
+    (.WriteHeader w 201)
+    (.Write w (go:convert (slice byte) (concatenate 'string "hello " (car (. (car (. r URL)) Path)))))))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, _ := ret.AsGoValue()
+	h, ok := v.(http.Handler)
+	if !ok {
+		t.Fatalf("want http.Handler but got %T", v)
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 201 || buf.String() != "hello /world" {
+		t.Fatalf("unexpected response: %v %q", resp.StatusCode, buf.String())
+	}
+}
+
+func TestGoImplementRegister(t *testing.T) {
+	env := NewEnv(nil)
+	if err := env.ImportTypes("example/greet", map[string]interface{}{
+		"Greeter": (*greeter)(nil),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	src := `(go:implement example/greet.Greeter :Greet (lambda (name) (concatenate 'string "hi " name)))`
+	if _, err := env.EvalString(context.Background(), src); err == nil || !strings.Contains(err.Error(), "can not implement") {
+		t.Fatalf("want error for interface without adapter but got %v", err)
+	}
+	if err := env.RegisterInterface((*greeter)(nil), func(funcs map[string]reflect.Value) interface{} {
+		return greeterFunc(funcs["Greet"].Interface().(func(string) string))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ret, err := env.EvalString(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, _ := ret.AsGoValue()
+	if got := v.(greeter).Greet("bob"); got != "hi bob" {
+		t.Fatalf("want %q but got %q", "hi bob", got)
+	}
+	if err := NewEnv(nil).RegisterInterface(greeterFunc(nil), nil); err == nil {
+		t.Fatal("want error for non-interface")
+	}
+
+	for _, src := range []string{
+		`(go:implement 'fmt.Stringer)`,
+		`(go:implement 'fmt.Stringer :String 1)`,
+		`(go:implement 'fmt.Stringer :Str (lambda () "a"))`,
+		`(go:implement 'net/http.Cookie :String (lambda () "a"))`,
+		`(go:implement 'net/http.ResponseWriter :Header (lambda () nil))`,
+		`(go:implement 'sort.Interface :Len (lambda () 0))`,
+	} {
+		if _, err := env.EvalString(context.Background(), src); err == nil {
+			t.Errorf("%s: want error", src)
+		}
+	}
+}
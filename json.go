@@ -0,0 +1,475 @@
+package golisp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonOptions are the options of json-parse and json-stringify.
+type jsonOptions struct {
+	objectAs    string // hash-table, alist or plist
+	arrayAs     string // vector or list
+	keywordKeys bool
+	sortKeys    bool
+	falseValue  *Node // the value of false, :false if not set
+	nullValue   *Node // the value of null, nil if not set
+}
+
+func (o jsonOptions) falseNode() *Node {
+	if o.falseValue != nil {
+		return o.falseValue
+	}
+	return Keyword("false")
+}
+
+func (o jsonOptions) nullNode() *Node {
+	if o.nullValue != nil {
+		return o.nullValue
+	}
+	return Nil()
+}
+
+// decodeJSON reads a JSON value from r. Objects become hash tables keeping
+// the order of their keys, or alists or plists, arrays vectors or lists,
+// true t, and false and null their values in opts, or :false and nil.
+func decodeJSON(r io.Reader, opts jsonOptions) (*Node, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	node, err := decodeJSONValue(dec, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: trailing data")
+	}
+	return node, nil
+}
+
+func decodeJSONValue(dec *json.Decoder, opts jsonOptions) (*Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			var keys, values []*Node
+			for dec.More() {
+				tok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJSONValue(dec, opts)
+				if err != nil {
+					return nil, err
+				}
+				key := String(tok.(string))
+				if opts.keywordKeys {
+					key = Keyword(tok.(string))
+				}
+				keys, values = append(keys, key), append(values, value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return jsonObject(keys, values, opts.objectAs), nil
+		case '[':
+			var items []*Node
+			for dec.More() {
+				item, err := decodeJSONValue(dec, opts)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			if opts.arrayAs == "list" {
+				return List(items...), nil
+			}
+			return Vector(items...), nil
+		}
+	case string:
+		return String(v), nil
+	case json.Number:
+		return jsonNumber(v)
+	case bool:
+		if v {
+			return T(), nil
+		}
+		return opts.falseNode(), nil
+	case nil:
+		return opts.nullNode(), nil
+	}
+	return nil, fmt.Errorf("invalid JSON token: %v", tok)
+}
+
+// jsonObject makes the object of keys and values as a hash table, an alist
+// or a plist.
+func jsonObject(keys, values []*Node, as string) *Node {
+	switch as {
+	case "alist":
+		pairs := make([]*Node, len(keys))
+		for i := range keys {
+			pairs[i] = Cons(keys[i], values[i])
+		}
+		return List(pairs...)
+	case "plist":
+		items := make([]*Node, 0, 2*len(keys))
+		for i := range keys {
+			items = append(items, keys[i], values[i])
+		}
+		return List(items...)
+	}
+	h := NewHashTable()
+	for i := range keys {
+		h.Set(keys[i], values[i])
+	}
+	return Hash(h)
+}
+
+// jsonNumber converts n to an integer if it is one that fits, or to a
+// float if float64 holds it exactly. Other integers become a *big.Int, and
+// other numbers are kept as a json.Number, so that they are encoded
+// unchanged.
+func jsonNumber(n json.Number) (*Node, error) {
+	s := string(n)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return Int(i), nil
+	}
+	if !strings.ContainsAny(s, ".eE") {
+		if i, ok := new(big.Int).SetString(s, 10); ok {
+			return GoValue(i), nil
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return GoValue(n), nil
+		}
+		return nil, fmt.Errorf("invalid JSON number: %v", n)
+	}
+	if short := strconv.FormatFloat(f, 'g', -1, 64); short != s {
+		want, _ := new(big.Rat).SetString(s)
+		got, _ := new(big.Rat).SetString(short)
+		if want == nil || got == nil || want.Cmp(got) != 0 {
+			return GoValue(n), nil
+		}
+	}
+	return Float(f), nil
+}
+
+// encodeJSON writes node to buf as JSON with the default options of
+// json-stringify.
+func encodeJSON(buf *bytes.Buffer, node *Node) error {
+	return encodeJSONValue(buf, node, jsonOptions{})
+}
+
+// encodeJSONValue writes node to buf as JSON. Hash tables and alists of
+// dotted pairs become objects in the order of their keys, or sorted with
+// opts.sortKeys, and other lists arrays, unless opts.objectAs makes alists
+// or plists objects. Vectors become arrays and t true. The values of false
+// and null in opts, or :false and nil, become false and null. Symbols and
+// keywords become strings of their names, and Go values are encoded with
+// encoding/json.
+func encodeJSONValue(buf *bytes.Buffer, node *Node, opts jsonOptions) error {
+	if node == nil {
+		node = Nil()
+	}
+	if eq(node, opts.nullNode()) {
+		buf.WriteString("null")
+		return nil
+	}
+	if eq(node, opts.falseNode()) {
+		buf.WriteString("false")
+		return nil
+	}
+	switch node.t {
+	case NodeT:
+		buf.WriteString("true")
+	case NodeInt:
+		buf.WriteString(strconv.FormatInt(node.v.(int64), 10))
+	case NodeDouble:
+		n := buf.Len()
+		if err := encodeJSONGo(buf, node.v); err != nil {
+			return err
+		}
+		// Keep floats floats, so that 1.0 is not read back as 1.
+		if !bytes.ContainsAny(buf.Bytes()[n:], ".eE") {
+			buf.WriteString(".0")
+		}
+	case NodeString:
+		return encodeJSONGo(buf, node.v)
+	case NodeIdent:
+		name, _ := designatorName(node)
+		return encodeJSONGo(buf, name)
+	case NodeCell:
+		if keys, values, ok := jsonPairs(node, opts.objectAs); ok {
+			return encodeJSONObject(buf, keys, values, opts)
+		}
+		return encodeJSONArray(buf, node.ToSlice(), opts)
+	case NodeVector:
+		return encodeJSONArray(buf, node.v.([]*Node), opts)
+	case NodeHash:
+		var keys, values []*Node
+		node.v.(*HashTable).Each(func(key, value *Node) error {
+			keys, values = append(keys, key), append(values, value)
+			return nil
+		})
+		return encodeJSONObject(buf, keys, values, opts)
+	case NodeGoValue:
+		rv := node.v.(reflect.Value)
+		if !rv.IsValid() || !rv.CanInterface() {
+			return fmt.Errorf("can not encode %v as JSON", node)
+		}
+		return encodeJSONGo(buf, rv.Interface())
+	default:
+		return fmt.Errorf("can not encode %v as JSON", node)
+	}
+	return nil
+}
+
+func encodeJSONArray(buf *bytes.Buffer, items []*Node, opts jsonOptions) error {
+	buf.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeJSONValue(buf, item, opts); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func encodeJSONObject(buf *bytes.Buffer, keys, values []*Node, opts jsonOptions) error {
+	names := make([]string, len(keys))
+	order := make([]int, len(keys))
+	for i, key := range keys {
+		k, err := jsonKey(key)
+		if err != nil {
+			return err
+		}
+		names[i], order[i] = k, i
+	}
+	if opts.sortKeys {
+		sort.SliceStable(order, func(i, j int) bool {
+			return names[order[i]] < names[order[j]]
+		})
+	}
+	buf.WriteByte('{')
+	for n, i := range order {
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeJSONGo(buf, names[i]); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := encodeJSONValue(buf, values[i], opts); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// jsonPairs returns the keys and values of node if it is an object: an
+// alist of dotted pairs, or with as alist, a list of conses, or with as
+// plist, a list of keywords and values.
+func jsonPairs(node *Node, as string) ([]*Node, []*Node, bool) {
+	items := node.ToSlice()
+	var keys, values []*Node
+	if as == "plist" {
+		if len(items)%2 != 0 {
+			return nil, nil, false
+		}
+		for i := 0; i < len(items); i += 2 {
+			if s, ok := symbolOf(items[i]); !ok || !s.IsKeyword() {
+				return nil, nil, false
+			}
+			keys, values = append(keys, items[i]), append(values, items[i+1])
+		}
+		return keys, values, true
+	}
+	for _, item := range items {
+		if item.t != NodeCell || item.car == nil {
+			return nil, nil, false
+		}
+		switch item.car.t {
+		case NodeString, NodeIdent, NodeInt:
+		default:
+			return nil, nil, false
+		}
+		value := item.cdr
+		if value == nil {
+			value = Nil()
+		}
+		if as != "alist" && (value.t == NodeCell || value.t == NodeNil) {
+			return nil, nil, false
+		}
+		keys, values = append(keys, item.car), append(values, value)
+	}
+	return keys, values, true
+}
+
+// jsonKey returns the object key for the hash table key node.
+func jsonKey(node *Node) (string, error) {
+	switch node.t {
+	case NodeString, NodeIdent:
+		name, _ := designatorName(node)
+		return name, nil
+	case NodeInt:
+		return strconv.FormatInt(node.v.(int64), 10), nil
+	}
+	return "", fmt.Errorf("invalid JSON object key: %v", node)
+}
+
+func encodeJSONGo(buf *bytes.Buffer, v interface{}) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// jsonInput returns the reader of the JSON text in node, a string or a Go
+// value implementing io.Reader.
+func jsonInput(node *Node) (io.Reader, error) {
+	if s, ok := node.AsString(); ok {
+		return strings.NewReader(s), nil
+	}
+	if node.t == NodeGoValue {
+		rv := node.v.(reflect.Value)
+		if rv.IsValid() && rv.CanInterface() {
+			if r, ok := rv.Interface().(io.Reader); ok {
+				return r, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("invalid JSON input: %v", node)
+}
+
+// jsonValueOptions sets the values of false and null in opts from the
+// :false and :null options.
+func jsonValueOptions(opts map[string]*Node, jopts *jsonOptions) {
+	if v, ok := opts["false"]; ok {
+		jopts.falseValue = v
+	}
+	if v, ok := opts["null"]; ok {
+		jopts.nullValue = v
+	}
+}
+
+// doJSONParse parses the JSON text in a string or a stream. Objects become
+// hash tables, or alists or plists with :object-as, and their keys strings,
+// or keywords with :keys :keyword. Arrays become vectors, or lists with
+// :array-as :list. false and null become :false and nil, or the values of
+// :false and :null.
+//
+//	(json-parse "{\"a\": [1, false]}" :object-as :alist :keys :keyword :false nil)
+func doJSONParse(env *Env, node *Node) (*Node, error) {
+	args, opts, err := keywordArgs(node, "json-parse", "object-as", "array-as", "keys", "false", "null")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, errors.New("invalid arguments for json-parse")
+	}
+	r, err := jsonInput(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var jopts jsonOptions
+	if v, ok := opts["object-as"]; ok {
+		jopts.objectAs, _ = designatorName(v)
+		switch jopts.objectAs {
+		case "hash-table", "alist", "plist":
+		default:
+			return nil, fmt.Errorf("invalid value for :object-as: %v", v)
+		}
+	}
+	if v, ok := opts["array-as"]; ok {
+		jopts.arrayAs, _ = designatorName(v)
+		switch jopts.arrayAs {
+		case "vector", "list":
+		default:
+			return nil, fmt.Errorf("invalid value for :array-as: %v", v)
+		}
+	}
+	if v, ok := opts["keys"]; ok {
+		switch name, _ := designatorName(v); name {
+		case "keyword":
+			jopts.keywordKeys = true
+		case "string":
+		default:
+			return nil, fmt.Errorf("invalid value for :keys: %v", v)
+		}
+	}
+	jsonValueOptions(opts, &jopts)
+	return decodeJSON(r, jopts)
+}
+
+// doJSONStringify returns the JSON text of a value. Lists are arrays, but
+// alists of dotted pairs, and with :object-as, alists of any conses or
+// plists, are objects. :pretty indents it with two spaces, or with the
+// string given, and :sort-keys sorts the keys of objects instead of keeping
+// their order. The values of :false and :null, :false and nil by default,
+// are written as false and null.
+//
+//	(json-stringify data :object-as :plist :pretty t :sort-keys t)
+func doJSONStringify(env *Env, node *Node) (*Node, error) {
+	args, opts, err := keywordArgs(node, "json-stringify", "object-as", "pretty", "sort-keys", "false", "null")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, errors.New("invalid arguments for json-stringify")
+	}
+	var jopts jsonOptions
+	if v, ok := opts["object-as"]; ok {
+		jopts.objectAs, _ = designatorName(v)
+		switch jopts.objectAs {
+		case "alist", "plist":
+		default:
+			return nil, fmt.Errorf("invalid value for :object-as: %v", v)
+		}
+	}
+	if v, ok := opts["sort-keys"]; ok && v.t != NodeNil {
+		jopts.sortKeys = true
+	}
+	jsonValueOptions(opts, &jopts)
+	var buf bytes.Buffer
+	if err := encodeJSONValue(&buf, args[0], jopts); err != nil {
+		return nil, err
+	}
+	indent := ""
+	if v, ok := opts["pretty"]; ok && v.t != NodeNil {
+		indent = "  "
+		if s, ok := v.AsString(); ok {
+			indent = s
+		}
+	}
+	if indent == "" {
+		return String(buf.String()), nil
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", indent); err != nil {
+		return nil, err
+	}
+	return String(out.String()), nil
+}
@@ -0,0 +1,145 @@
+package golisp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	env := NewEnv(nil)
+	tests := []struct {
+		src   string
+		parse string
+		write string
+	}{
+		{src: `{"name":"日本語 é   😀","escaped":"\"\\\n\t<&>"}`},
+		{src: `[123456789012345678901234567890,-123456789012345678901234567890,9223372036854775807,-9223372036854775808]`},
+		{src: `[0.1,1.0,1.5e+300,1e400,-2.5e-10,3.14159265358979323846]`},
+		{src: `{"z":{"y":[true,null,{}],"x":[]},"a":"first"}`},
+		{src: `{"c":false,"x":[],"y":[false,null],"z":null}`},
+		{src: `{"x":[],"y":false,"z":null}`, parse: `:false :no :null :none`, write: `:false :no :null :none`},
+		{src: `{"a":["x"],"b":{"c":[1,{"d":"e"}]},"f":false}`, parse: `:object-as :alist`, write: `:object-as :alist`},
+		{src: `{"a":["x"],"b":{"c":[1,{"d":"e"}]},"f":false}`, parse: `:object-as :alist :keys :keyword`, write: `:object-as :alist`},
+		{src: `{"a":["x"],"b":{"c":[1,{"d":"e"}]},"f":false}`, parse: `:object-as :plist :keys :keyword`, write: `:object-as :plist`},
+	}
+	for _, test := range tests {
+		env.Define("src", test.src)
+		got, err := env.EvalString(context.Background(), `(json-stringify (json-parse src `+test.parse+`) `+test.write+`)`)
+		if err != nil {
+			t.Fatalf("%v: %v", test.src, err)
+		}
+		if s, _ := got.AsString(); s != test.src {
+			t.Errorf("want %v but got %v", test.src, s)
+		}
+	}
+}
+
+func TestJSONParse(t *testing.T) {
+	env := NewEnv(nil)
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{
+			input: `(json-parse "{\"a\": [1, 2.5, \"x\"], \"b\": false}")`,
+			want:  `#s(hash-table "a" #(1 2.5 "x") "b" :false)`,
+		},
+		{
+			input: `(json-parse "{\"a\": {\"b\": 1}}" :object-as :alist)`,
+			want:  `(("a" ("b" . 1)))`,
+		},
+		{
+			input: `(json-parse "{\"a\": {\"b\": 1}}" :object-as :plist :keys :keyword)`,
+			want:  `(:a (:b 1))`,
+		},
+		{
+			input: `(gethash :a (json-parse "{\"a\": 1}" :keys :keyword))`,
+			want:  `1`,
+		},
+		{
+			input: `(json-stringify (make-hash-table))`,
+			want:  `"{}"`,
+		},
+		{
+			input: `(json-stringify '(:b 1 :a (:d 1 :c 2)) :object-as :plist :sort-keys t)`,
+			want:  `"{\"a\":{\"c\":2,\"d\":1},\"b\":1}"`,
+		},
+		{
+			input: `(json-stringify '(:b 1 :a 2) :object-as :plist)`,
+			want:  `"{\"b\":1,\"a\":2}"`,
+		},
+		{
+			input: `(json-stringify '(:b 1 :a 2))`,
+			want:  `"[\"b\",1,\"a\",2]"`,
+		},
+		{
+			input: `(json-stringify '((:a . 1)) :pretty t)`,
+			want:  `"{\n  \"a\": 1\n}"`,
+		},
+		{
+			input: `(json-stringify '(:a :b :c))`,
+			want:  `"[\"a\",\"b\",\"c\"]"`,
+		},
+		{
+			input: `(json-stringify '(:red :green))`,
+			want:  `"[\"red\",\"green\"]"`,
+		},
+		{
+			input: `(json-stringify '(("x" "y") ("z" "w")))`,
+			want:  `"[[\"x\",\"y\"],[\"z\",\"w\"]]"`,
+		},
+		{
+			input: `(json-stringify '(("x" . "y") (:z . 1)))`,
+			want:  `"{\"x\":\"y\",\"z\":1}"`,
+		},
+		{
+			input: `(json-stringify '(nil :false t))`,
+			want:  `"[null,false,true]"`,
+		},
+		{
+			input: `(json-stringify (vector :red :green))`,
+			want:  `"[\"red\",\"green\"]"`,
+		},
+		{
+			input: `(json-stringify (json-parse "[{\"b\":1,\"a\":2}]") :sort-keys t)`,
+			want:  `"[{\"a\":2,\"b\":1}]"`,
+		},
+		{
+			input: `(json-parse "[1, [2], false, null]" :array-as :list :false nil)`,
+			want:  `(1 (2) nil nil)`,
+		},
+		{
+			input: `(json-stringify '(("a" "x" "y") ("b" . 1)) :object-as :alist)`,
+			want:  `"{\"a\":[\"x\",\"y\"],\"b\":1}"`,
+		},
+		{
+			input: `(json-stringify (list 1.0 2.5 (json-parse "1.00")))`,
+			want:  `"[1.0,2.5,1.0]"`,
+		},
+	}
+	for _, test := range tests {
+		got, err := env.EvalString(context.Background(), test.input)
+		if err != nil {
+			t.Fatalf("%v: %v", test.input, err)
+		}
+		if got.String() != test.want {
+			t.Errorf("want %v for %v but got %v", test.want, test.input, got)
+		}
+	}
+
+	for _, src := range []string{
+		`(json-parse "[1")`,
+		`(json-parse "1 2")`,
+		`(json-parse 1)`,
+		`(json-parse "{}" :object-as :vector)`,
+		`(json-parse "{}" :keys :symbol)`,
+		`(json-parse "[]" :array-as :hash-table)`,
+		`(json-stringify '(:a 1) :object-as :hash-table)`,
+		`(json-stringify 1 2)`,
+		`(json-stringify (make-hash-table) :indent 2)`,
+	} {
+		if _, err := env.EvalString(context.Background(), src); err == nil {
+			t.Errorf("%s: want error", src)
+		}
+	}
+}
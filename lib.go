@@ -1,44 +1,51 @@
 package golisp
 
 import (
+	"embed"
+	"io/fs"
+	"os"
 	"path"
-
-	"github.com/rakyll/statik/fs"
+	"strings"
 )
 
-//go:generate statik -src=lib
+//go:embed lib
+var lib embed.FS
 
+// LoadLib loads every file of the built-in library into env.
 func LoadLib(env *Env) error {
-	statikFS, err := fs.New()
-	if err != nil {
-		return err
-	}
-	dir, err := statikFS.Open("/")
-	if err != nil {
-		return err
+	return loadLibDir(env, lib, "lib")
+}
+
+// LoadLibDir loads every .lisp file in dir of the filesystem set with
+// SetFS, or of the OS filesystem, into env instead of the built-in library.
+// require also looks for modules in dir.
+func LoadLibDir(env *Env, dir string) error {
+	fsys := env.root().fsys
+	if fsys == nil {
+		return loadLibDir(env, os.DirFS(dir), ".")
 	}
-	defer dir.Close()
+	return loadLibDir(env, fsys, fsPath(dir))
+}
+
+// loadLibDir loads the library files in dir of fsys. Each file is recorded
+// as a feature, so a later require of it does nothing.
+func loadLibDir(env *Env, fsys fs.FS, dir string) error {
+	root := env.root()
+	root.lib, root.libDir = fsys, dir
 
-	fis, err := dir.Readdir(-1)
+	fis, err := fs.ReadDir(fsys, dir)
 	if err != nil {
 		return err
 	}
 	for _, fi := range fis {
-		f, err := statikFS.Open(path.Join("/", fi.Name()))
-		if err != nil {
-			return err
-		}
-		node, err := NewParser(f).Parse()
-		if err != nil {
-			f.Close()
-			return err
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".lisp") {
+			continue
 		}
-		_, err = env.Eval(node)
+		_, err = loadSource(env, &source{fsys: fsys, path: path.Join(dir, fi.Name())}, nil)
 		if err != nil {
-			f.Close()
 			return err
 		}
-		f.Close()
+		root.provide(strings.TrimSuffix(fi.Name(), ".lisp"))
 	}
 
 	return nil
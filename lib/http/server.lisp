@@ -0,0 +1,30 @@
+; Helpers for the HTTP server: (require 'http/server)
+; They are exported from the http package, as in (http:ok "hello").
+
+(defpackage :http
+  (:export :ok :created :bad-request :unauthorized :not-found :redirect
+           :log-requests :require-header))
+(in-package :http)
+
+(defun ok (body) (response body :status 200))
+(defun created (location body)
+  (response body :status 201 :headers (list (cons "Location" location))))
+(defun bad-request (message) (response message :status 400))
+(defun unauthorized () (response "unauthorized" :status 401))
+(defun not-found () (response "not found" :status 404))
+(defun redirect (url)
+  (response "" :status 302 :headers (list (cons "Location" url))))
+
+; Middleware printing the method and path of each request.
+(defun log-requests (next)
+  (lambda (req)
+    (print (concatenate 'string (req-method req) " " (req-path req)))
+    (funcall next req)))
+
+; Middleware rejecting requests without the header name.
+(defun require-header (name)
+  (lambda (next)
+    (lambda (req)
+      (cond
+        ((null (req-header req name)) (unauthorized))
+        (t (funcall next req))))))
@@ -0,0 +1,479 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Marshal converts a Go value to Lisp data. Numbers, strings and bools
+// become native nodes, slices and arrays become lists, maps become alists
+// sorted by key, and structs become plists keyed by keywords. Struct fields
+// are named by the lisp tag, or by the field name in kebab-case:
+//
+//	Name   string `lisp:"name"`
+//	UserID int    // :user-id
+//	Secret string `lisp:"-"`
+//
+// Values without a Lisp counterpart, like channels and funcs, are wrapped
+// as Go values.
+func Marshal(v interface{}) (*Node, error) {
+	return marshalValue(reflect.ValueOf(v))
+}
+
+func marshalValue(rv reflect.Value) (*Node, error) {
+	if !rv.IsValid() {
+		return Nil(), nil
+	}
+	if rv.Type() == nodeType {
+		return valueToNode(rv), nil
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return Nil(), nil
+		}
+		return marshalValue(rv.Elem())
+	case reflect.Slice:
+		if rv.IsNil() {
+			return Nil(), nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return String(string(rv.Bytes())), nil
+		}
+		fallthrough
+	case reflect.Array:
+		items := make([]*Node, rv.Len())
+		for i := range items {
+			item, err := marshalValue(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return List(items...), nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return Nil(), nil
+		}
+		items := make([]*Node, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k, err := marshalValue(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			v, err := marshalValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, Cons(k, v))
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].car.String() < items[j].car.String()
+		})
+		return List(items...), nil
+	case reflect.Struct:
+		var items []*Node
+		err := marshalStruct(rv, &items)
+		if err != nil {
+			return nil, err
+		}
+		return List(items...), nil
+	}
+	return valueToNode(rv), nil
+}
+
+func marshalStruct(rv reflect.Value, items *[]*Node) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		name, omitempty, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		fv := rv.Field(i)
+		if f.Anonymous && f.Tag.Get("lisp") == "" {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := marshalStruct(fv, items); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if omitempty && fv.IsZero() {
+			continue
+		}
+		v, err := marshalValue(fv)
+		if err != nil {
+			return err
+		}
+		*items = append(*items, Keyword(name), v)
+	}
+	return nil
+}
+
+// fieldName returns the Lisp name of the struct field f.
+func fieldName(f reflect.StructField) (string, bool, bool) {
+	if f.PkgPath != "" && !f.Anonymous {
+		return "", false, false
+	}
+	tag := f.Tag.Get("lisp")
+	if tag == "-" {
+		return "", false, false
+	}
+	opts := strings.Split(tag, ",")
+	name := opts[0]
+	if name == "" {
+		name = kebabCase(f.Name)
+	}
+	omitempty := false
+	for _, opt := range opts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, true
+}
+
+// kebabCase converts a Go name like UserID to user-id.
+func kebabCase(s string) string {
+	var buf strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) ||
+				(unicode.IsUpper(rs[i-1]) && i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				buf.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// Unmarshal stores the Lisp data n into the value pointed to by v. It is
+// the inverse of Marshal; structs and maps accept both plists and alists.
+func Unmarshal(n *Node, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Unmarshal(non-pointer %T)", v)
+	}
+	if n == nil {
+		n = Nil()
+	}
+	return unmarshalValue(n, rv.Elem())
+}
+
+func unmarshalError(node *Node, typ reflect.Type) error {
+	return fmt.Errorf("cannot use %v as %v", node, typ)
+}
+
+func unmarshalValue(node *Node, rv reflect.Value) error {
+	typ := rv.Type()
+	if typ == nodeType {
+		rv.Set(reflect.ValueOf(node))
+		return nil
+	}
+	if node.t == NodeGoValue {
+		gv := node.v.(reflect.Value)
+		if !gv.IsValid() {
+			rv.Set(reflect.Zero(typ))
+			return nil
+		}
+		if gv.Type().AssignableTo(typ) {
+			rv.Set(gv)
+			return nil
+		}
+		if gv.Type().ConvertibleTo(typ) {
+			rv.Set(gv.Convert(typ))
+			return nil
+		}
+		if typ.Kind() == reflect.Ptr && gv.CanAddr() && gv.Addr().Type().AssignableTo(typ) {
+			rv.Set(gv.Addr())
+			return nil
+		}
+		if gv.Kind() == reflect.Ptr && !gv.IsNil() && gv.Elem().Type().AssignableTo(typ) {
+			rv.Set(gv.Elem())
+			return nil
+		}
+		return fmt.Errorf("cannot use %v as %v", gv.Type(), typ)
+	}
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		if node.t == NodeNil {
+			rv.Set(reflect.Zero(typ))
+			return nil
+		}
+		v := reflect.ValueOf(nodeInterface(node))
+		if v.IsValid() && v.Type().AssignableTo(typ) {
+			rv.Set(v)
+			return nil
+		}
+	case reflect.Ptr:
+		if node.t == NodeNil {
+			rv.Set(reflect.Zero(typ))
+			return nil
+		}
+		p := reflect.New(typ.Elem())
+		if err := unmarshalValue(node, p.Elem()); err != nil {
+			return err
+		}
+		rv.Set(p)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch node.t {
+		case NodeInt:
+			i = node.v.(int64)
+		case NodeDouble:
+			f := node.v.(float64)
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return fmt.Errorf("%v is not an integer for %v", f, typ)
+			}
+			i = int64(f)
+		default:
+			return unmarshalError(node, typ)
+		}
+		if rv.OverflowInt(i) {
+			return fmt.Errorf("%v overflows %v", i, typ)
+		}
+		rv.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var i int64
+		switch node.t {
+		case NodeInt:
+			i = node.v.(int64)
+		case NodeDouble:
+			f := node.v.(float64)
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return fmt.Errorf("%v is not an integer for %v", f, typ)
+			}
+			i = int64(f)
+		default:
+			return unmarshalError(node, typ)
+		}
+		if i < 0 || rv.OverflowUint(uint64(i)) {
+			return fmt.Errorf("%v overflows %v", i, typ)
+		}
+		rv.SetUint(uint64(i))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch node.t {
+		case NodeInt:
+			f = float64(node.v.(int64))
+		case NodeDouble:
+			f = node.v.(float64)
+		default:
+			return unmarshalError(node, typ)
+		}
+		if rv.OverflowFloat(f) {
+			return fmt.Errorf("%v overflows %v", f, typ)
+		}
+		rv.SetFloat(f)
+		return nil
+	case reflect.String:
+		if s, ok := designatorName(node); ok {
+			rv.SetString(s)
+			return nil
+		}
+	case reflect.Bool:
+		switch node.t {
+		case NodeNil:
+			rv.SetBool(false)
+			return nil
+		case NodeT:
+			rv.SetBool(true)
+			return nil
+		}
+	case reflect.Slice:
+		switch node.t {
+		case NodeNil:
+			rv.Set(reflect.Zero(typ))
+			return nil
+		case NodeString:
+			if k := typ.Elem().Kind(); k == reflect.Uint8 || k == reflect.Int32 {
+				rv.Set(reflect.ValueOf(node.v.(string)).Convert(typ))
+				return nil
+			}
+		case NodeCell, NodeVector:
+			items := sequenceItems(node)
+			sv := reflect.MakeSlice(typ, len(items), len(items))
+			for i, item := range items {
+				if err := unmarshalValue(item, sv.Index(i)); err != nil {
+					return err
+				}
+			}
+			rv.Set(sv)
+			return nil
+		}
+	case reflect.Array:
+		switch node.t {
+		case NodeNil, NodeCell, NodeVector:
+			items := sequenceItems(node)
+			if len(items) > rv.Len() {
+				return fmt.Errorf("%v overflows %v", node, typ)
+			}
+			for i, item := range items {
+				if err := unmarshalValue(item, rv.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		switch node.t {
+		case NodeNil:
+			rv.Set(reflect.Zero(typ))
+			return nil
+		case NodeCell, NodeHash:
+			pairs, err := entries(node)
+			if err != nil {
+				return err
+			}
+			mv := reflect.MakeMapWithSize(typ, len(pairs))
+			for _, pair := range pairs {
+				k := reflect.New(typ.Key()).Elem()
+				if err := unmarshalValue(pair[0], k); err != nil {
+					return err
+				}
+				v := reflect.New(typ.Elem()).Elem()
+				if err := unmarshalValue(pair[1], v); err != nil {
+					return err
+				}
+				mv.SetMapIndex(k, v)
+			}
+			rv.Set(mv)
+			return nil
+		}
+	case reflect.Struct:
+		switch node.t {
+		case NodeNil, NodeCell, NodeHash:
+			pairs, err := entries(node)
+			if err != nil {
+				return err
+			}
+			for _, pair := range pairs {
+				name, ok := designatorName(pair[0])
+				if !ok {
+					return fmt.Errorf("invalid field name: %v", pair[0])
+				}
+				fv, ok, err := structField(rv, name)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+				if err := unmarshalValue(pair[1], fv); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Func, reflect.Chan:
+		if node.t == NodeNil {
+			rv.Set(reflect.Zero(typ))
+			return nil
+		}
+	}
+	return unmarshalError(node, typ)
+}
+
+// sequenceItems returns the elements of a list or a vector.
+func sequenceItems(node *Node) []*Node {
+	if node.t == NodeVector {
+		return node.v.([]*Node)
+	}
+	return node.ToSlice()
+}
+
+// entries returns the key/value pairs of an alist or a plist.
+func entries(node *Node) ([][2]*Node, error) {
+	if node.t == NodeHash {
+		var pairs [][2]*Node
+		node.v.(*HashTable).Each(func(k, v *Node) error {
+			pairs = append(pairs, [2]*Node{k, v})
+			return nil
+		})
+		return pairs, nil
+	}
+	items := node.ToSlice()
+	var pairs [][2]*Node
+	if len(items) > 0 && items[0].t == NodeCell {
+		for _, item := range items {
+			if item.t != NodeCell {
+				return nil, fmt.Errorf("invalid alist entry: %v", item)
+			}
+			pairs = append(pairs, [2]*Node{item.Car(), item.Cdr()})
+		}
+		return pairs, nil
+	}
+	if len(items)%2 != 0 {
+		return nil, errors.New("odd number of elements in plist")
+	}
+	for i := 0; i < len(items); i += 2 {
+		pairs = append(pairs, [2]*Node{items[i], items[i+1]})
+	}
+	return pairs, nil
+}
+
+// structField returns the field of the struct rv with the Lisp name name.
+// Like encoding/json, it fails if the field is in a nil embedded pointer to
+// an unexported struct, which can not be allocated.
+func structField(rv reflect.Value, name string) (reflect.Value, bool, error) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		fname, _, ok := fieldName(f)
+		if !ok {
+			continue
+		}
+		fv := rv.Field(i)
+		if f.Anonymous && f.Tag.Get("lisp") == "" {
+			if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+				if fv.IsNil() {
+					elem := reflect.New(fv.Type().Elem())
+					if _, ok, _ := structField(elem.Elem(), name); !ok {
+						continue
+					}
+					if !fv.CanSet() {
+						return reflect.Value{}, false, fmt.Errorf("can not set embedded pointer to unexported struct %v", fv.Type().Elem())
+					}
+					fv.Set(elem)
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if v, ok, err := structField(fv, name); ok || err != nil {
+					return v, ok, err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if strings.EqualFold(fname, name) || strings.EqualFold(f.Name, name) {
+			return fv, true, nil
+		}
+	}
+	return reflect.Value{}, false, nil
+}
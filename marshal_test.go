@@ -0,0 +1,116 @@
+package golisp
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	City string `lisp:"city"`
+	Zip  string `lisp:"zip,omitempty"`
+}
+
+type testUser struct {
+	Name    string
+	UserID  int
+	Tags    []string
+	Scores  map[string]float64
+	Address *testAddress
+	Secret  string `lisp:"-"`
+}
+
+func TestMarshal(t *testing.T) {
+	u := testUser{
+		Name:    "bob",
+		UserID:  3,
+		Tags:    []string{"a", "b"},
+		Scores:  map[string]float64{"y": 2.5, "x": 1},
+		Address: &testAddress{City: "Tokyo"},
+		Secret:  "s",
+	}
+	n, err := Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `(:name "bob" :user-id 3 :tags ("a" "b") :scores (("x" . 1) ("y" . 2.5)) :address (:city "Tokyo"))`
+	if got := n.String(); got != want {
+		t.Fatalf("want %q but got %q", want, got)
+	}
+
+	var got testUser
+	if err := Unmarshal(n, &got); err != nil {
+		t.Fatal(err)
+	}
+	u.Secret = ""
+	if !reflect.DeepEqual(got, u) {
+		t.Fatalf("want %#v but got %#v", u, got)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	env := NewEnv(nil)
+	n, err := evalString(t, env, `'((name . "alice") (user-id . 7) (tags "x" "y"))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u testUser
+	if err := Unmarshal(n, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "alice" || u.UserID != 7 || !reflect.DeepEqual(u.Tags, []string{"x", "y"}) {
+		t.Fatalf("unexpected result: %#v", u)
+	}
+
+	var m map[string]int
+	n, err = evalString(t, env, `'(:a 1 :b 2)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Unmarshal(n, &m); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, map[string]int{"a": 1, "b": 2}) {
+		t.Fatalf("unexpected result: %#v", m)
+	}
+
+	var i8 int8
+	if err := Unmarshal(Int(300), &i8); err == nil {
+		t.Fatal("want overflow error")
+	}
+	var n64 int64
+	var u64 uint64
+	if err := Unmarshal(Float(1.5), &n64); err == nil {
+		t.Fatal("want error for 1.5 into int64")
+	}
+	if err := Unmarshal(Float(1.5), &u64); err == nil {
+		t.Fatal("want error for 1.5 into uint64")
+	}
+	if err := Unmarshal(Float(2), &n64); err != nil || n64 != 2 {
+		t.Fatalf("want 2 but got %v, %v", n64, err)
+	}
+	var v interface{}
+	if err := Unmarshal(List(Int(1), String("a"), T()), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, []interface{}{int64(1), "a", true}) {
+		t.Fatalf("unexpected result: %#v", v)
+	}
+	if err := Unmarshal(Int(1), v); err == nil {
+		t.Fatal("want error for non-pointer")
+	}
+
+	var e struct {
+		*testAddress
+		Name string
+	}
+	if err := Unmarshal(List(Keyword("name"), String("bob")), &e); err != nil || e.Name != "bob" || e.testAddress != nil {
+		t.Fatalf("unexpected result: %#v, %v", e, err)
+	}
+	if err := Unmarshal(List(Keyword("city"), String("Tokyo")), &e); err == nil {
+		t.Fatal("want error for nil embedded pointer to unexported struct")
+	}
+	e.testAddress = &testAddress{}
+	if err := Unmarshal(List(Keyword("city"), String("Tokyo")), &e); err != nil || e.City != "Tokyo" {
+		t.Fatalf("unexpected result: %#v, %v", e, err)
+	}
+}
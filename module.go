@@ -0,0 +1,251 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var symLoadPath = intern("*load-path*")
+
+// source is a file to load, either on the OS filesystem or in an fs.FS.
+type source struct {
+	fsys fs.FS
+	path string
+}
+
+func (s *source) open() (io.ReadCloser, error) {
+	if s.fsys == nil {
+		return os.Open(s.path)
+	}
+	return s.fsys.Open(s.path)
+}
+
+func (s *source) exists() bool {
+	f, err := s.open()
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+// resolve returns the source for name relative to the directory of s.
+func (s *source) resolve(name string) *source {
+	if s.fsys == nil {
+		if filepath.IsAbs(name) {
+			return &source{path: name}
+		}
+		return &source{path: filepath.Join(filepath.Dir(s.path), name)}
+	}
+	if path.IsAbs(name) {
+		return &source{fsys: s.fsys, path: fsPath(name)}
+	}
+	return &source{fsys: s.fsys, path: fsPath(path.Join(path.Dir(s.path), name))}
+}
+
+func (s *source) String() string {
+	return s.path
+}
+
+// fsPath turns name into a path valid for fs.FS. Names can not point
+// outside of the filesystem.
+func fsPath(name string) string {
+	p := path.Clean("/" + filepath.ToSlash(name))[1:]
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// SetFS makes load, require and LoadLibDir read files from fsys instead of
+// the OS filesystem. Paths are slash separated and relative to the root of
+// fsys.
+func (e *Env) SetFS(fsys fs.FS) {
+	e.root().fsys = fsys
+}
+
+// loadFrame returns the innermost frame of env evaluating a loaded file, or
+// nil outside of one.
+func loadFrame(env *Env) *Env {
+	for e := env; e != nil; e = e.env {
+		if e.file != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// loadingModules returns the modules being required where env evaluates.
+func loadingModules(env *Env) []string {
+	if f := loadFrame(env); f != nil {
+		return f.loading
+	}
+	return nil
+}
+
+// openSource returns the source for name as given to load.
+func openSource(env *Env, name string) *source {
+	root := env.root()
+	if f := loadFrame(env); f != nil {
+		return f.file.resolve(name)
+	}
+	if root.fsys != nil {
+		return &source{fsys: root.fsys, path: fsPath(name)}
+	}
+	return &source{path: name}
+}
+
+func loadPath() *Node {
+	head := &Node{
+		t: NodeNil,
+	}
+	dirs := filepath.SplitList(os.Getenv("GOLISP_PATH"))
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] == "" {
+			continue
+		}
+		head = &Node{
+			t: NodeCell,
+			car: &Node{
+				t: NodeString,
+				v: dirs[i],
+			},
+			cdr: head,
+		}
+	}
+	return head
+}
+
+// loadSource evaluates the file s in a frame of env, which records the
+// file and the modules being required, if any. Relative loads and requires
+// inside the file are resolved against its directory. Keeping them in the
+// frame lets goroutines load files at the same time.
+func loadSource(env *Env, s *source, loading []string) (*Node, error) {
+	f, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	curr, err := NewParser(f).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", s, err)
+	}
+
+	scope := NewEnv(env)
+	scope.file = s
+	scope.loading = loading
+	return evalForms(scope, curr)
+}
+
+// findModule looks for name.lisp next to the file being loaded, then in
+// each directory of *load-path*, then in the library directory.
+func findModule(env *Env, name string) (*source, error) {
+	file := name
+	if !strings.HasSuffix(file, ".lisp") {
+		file += ".lisp"
+	}
+	root := env.root()
+	// A sandboxed script may only read modules from the filesystem set
+	// with SetFS or from the library.
+	host := root.sandbox == nil
+	if f := loadFrame(env); f != nil && (host || f.file.fsys != nil) {
+		if s := f.file.resolve(file); s.exists() {
+			return s, nil
+		}
+	}
+	if v, ok := root.getVar(symLoadPath); ok && (host || root.fsys != nil) {
+		for curr := v; curr != nil && curr.t == NodeCell; curr = curr.cdr {
+			if curr.car == nil || curr.car.t != NodeString {
+				continue
+			}
+			var s *source
+			if root.fsys != nil {
+				s = &source{fsys: root.fsys, path: fsPath(path.Join(curr.car.v.(string), file))}
+			} else {
+				s = &source{path: filepath.Join(curr.car.v.(string), file)}
+			}
+			if s.exists() {
+				return s, nil
+			}
+		}
+	}
+	s := &source{fsys: root.lib, path: path.Join(root.libDir, file)}
+	if s.exists() {
+		return s, nil
+	}
+	return nil, fmt.Errorf("cannot find module: %v", name)
+}
+
+func (e *Env) provided(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.features[name]
+}
+
+func (e *Env) provide(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.features[name] = true
+}
+
+func doRequire(env *Env, node *Node) (*Node, error) {
+	name, ok := designatorName(node.car)
+	if !ok {
+		return nil, errors.New("invalid arguments for require")
+	}
+	root := env.root()
+	if root.provided(name) {
+		return &Node{
+			t: NodeNil,
+		}, nil
+	}
+	loading := loadingModules(env)
+	for i, m := range loading {
+		if m == name {
+			cycle := append(append([]string{}, loading[i:]...), name)
+			return nil, fmt.Errorf("circular require: %v", strings.Join(cycle, " -> "))
+		}
+	}
+
+	var s *source
+	var err error
+	if node.cdr != nil && node.cdr.car != nil {
+		if node.cdr.car.t != NodeString {
+			return nil, errors.New("invalid arguments for require")
+		}
+		s = openSource(env, node.cdr.car.v.(string))
+	} else {
+		s, err = findModule(env, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if root.sandbox != nil && s.fsys == nil {
+		return nil, fmt.Errorf("%w: require %v", ErrForbidden, name)
+	}
+	_, err = loadSource(root, s, append(loading[:len(loading):len(loading)], name))
+	if err != nil {
+		return nil, err
+	}
+	root.provide(name)
+	return &Node{
+		t: NodeT,
+		v: true,
+	}, nil
+}
+
+func doProvide(env *Env, node *Node) (*Node, error) {
+	name, ok := designatorName(node.car)
+	if !ok {
+		return nil, errors.New("invalid arguments for provide")
+	}
+	env.root().provide(name)
+	return node.car, nil
+}
@@ -0,0 +1,99 @@
+package golisp
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRequireCycle(t *testing.T) {
+	node, err := NewParser(strings.NewReader(`
+(setq *load-path* (list "testdata/require"))
+(require 'cycle-a)
+`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewEnv(nil).Eval(node)
+	want := "circular require: cycle-a -> cycle-b -> cycle-a"
+	if err == nil || err.Error() != want {
+		t.Fatalf("want %q but got %v", want, err)
+	}
+}
+
+func TestRequireLib(t *testing.T) {
+	node, err := NewParser(strings.NewReader(`
+(require 'nth)
+(nth '(1 2 3) 2)
+`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := NewEnv(nil).Eval(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ret.String(); got != "3" {
+		t.Fatalf("want %q but got %q", "3", got)
+	}
+}
+
+func TestSetFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"mylib/twice.lisp":  {Data: []byte(`(defun twice (x) (* x 2))`)},
+		"scripts/main.lisp": {Data: []byte(`(require 'util) (defun main () (inc (twice 20)))`)},
+		"scripts/util.lisp": {Data: []byte(`(defun inc (x) (+ x 2))`)},
+	}
+	env := NewEnv(nil)
+	env.SetFS(fsys)
+	if err := LoadLibDir(env, "mylib"); err != nil {
+		t.Fatal(err)
+	}
+	node, err := NewParser(strings.NewReader(`
+(load "scripts/main.lisp")
+(require 'twice)
+(main)
+`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := env.Eval(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ret.String(); got != "42" {
+		t.Fatalf("want %q but got %q", "42", got)
+	}
+
+	node, err = NewParser(strings.NewReader(`(load "testdata/require/names.lisp")`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = env.Eval(node); err == nil {
+		t.Fatal("want error for file outside of the filesystem")
+	}
+}
+
+func TestRequireConcurrent(t *testing.T) {
+	fsys := fstest.MapFS{}
+	var src strings.Builder
+	src.WriteString("(setq *load-path* (list \"mods\"))\n(with-task-group\n")
+	for i := 0; i < 8; i++ {
+		n := strconv.Itoa(i)
+		fsys["mods/m"+n+".lisp"] = &fstest.MapFile{Data: []byte(`(require 'h` + n + `) (defun f` + n + ` () (h` + n + `))`)}
+		fsys["mods/h"+n+".lisp"] = &fstest.MapFile{Data: []byte(`(defun h` + n + ` () ` + n + `)`)}
+		src.WriteString("  (go (require 'm" + n + "))\n")
+	}
+	src.WriteString(")\n(+ (f0) (f1) (f2) (f3) (f4) (f5) (f6) (f7))\n")
+	env := NewEnv(nil)
+	env.SetFS(fsys)
+	ret, err := env.EvalString(context.Background(), src.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ret.String(); got != "28" {
+		t.Fatalf("want %q but got %q", "28", got)
+	}
+}
@@ -0,0 +1,214 @@
+package golisp
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Nil returns the empty list.
+func Nil() *Node {
+	return &Node{
+		t: NodeNil,
+	}
+}
+
+// T returns t.
+func T() *Node {
+	return &Node{
+		t: NodeT,
+		v: true,
+	}
+}
+
+// Bool returns t for true and nil for false.
+func Bool(b bool) *Node {
+	if b {
+		return T()
+	}
+	return Nil()
+}
+
+func Int(i int64) *Node {
+	return &Node{
+		t: NodeInt,
+		v: i,
+	}
+}
+
+func Float(f float64) *Node {
+	return &Node{
+		t: NodeDouble,
+		v: f,
+	}
+}
+
+func String(s string) *Node {
+	return &Node{
+		t: NodeString,
+		v: s,
+	}
+}
+
+// Symbol returns the interned symbol named name.
+func Symbol(name string) *Node {
+	return &Node{
+		t: NodeIdent,
+		v: intern(name),
+	}
+}
+
+// Keyword returns the keyword :name.
+func Keyword(name string) *Node {
+	return Symbol(":" + strings.TrimPrefix(name, ":"))
+}
+
+// GoValue wraps an arbitrary Go value.
+func GoValue(v interface{}) *Node {
+	return &Node{
+		t: NodeGoValue,
+		v: reflect.ValueOf(v),
+	}
+}
+
+func Cons(car, cdr *Node) *Node {
+	return &Node{
+		t:   NodeCell,
+		car: car,
+		cdr: cdr,
+	}
+}
+
+// List returns a proper list of nodes.
+func List(nodes ...*Node) *Node {
+	ret := Nil()
+	for i := len(nodes) - 1; i >= 0; i-- {
+		ret = Cons(nodes[i], ret)
+	}
+	return ret
+}
+
+func (n *Node) Type() NodeType {
+	if n == nil {
+		return NodeNil
+	}
+	return n.t
+}
+
+// Car returns the first element of a cons cell, or nil.
+func (n *Node) Car() *Node {
+	if n == nil || n.t != NodeCell || n.car == nil {
+		return Nil()
+	}
+	return n.car
+}
+
+// Cdr returns the rest of a cons cell, or nil.
+func (n *Node) Cdr() *Node {
+	if n == nil || n.t != NodeCell || n.cdr == nil {
+		return Nil()
+	}
+	return n.cdr
+}
+
+// IsNil reports whether n is nil or the empty list.
+func (n *Node) IsNil() bool {
+	return n == nil || n.t == NodeNil
+}
+
+func (n *Node) AsInt() (int64, bool) {
+	if n == nil || n.t != NodeInt {
+		return 0, false
+	}
+	return n.v.(int64), true
+}
+
+// AsFloat returns the value of a float or an int.
+func (n *Node) AsFloat() (float64, bool) {
+	if n == nil {
+		return 0, false
+	}
+	switch n.t {
+	case NodeDouble:
+		return n.v.(float64), true
+	case NodeInt:
+		return float64(n.v.(int64)), true
+	}
+	return 0, false
+}
+
+func (n *Node) AsString() (string, bool) {
+	if n == nil || n.t != NodeString {
+		return "", false
+	}
+	return n.v.(string), true
+}
+
+// AsBool returns false for nil and true for t.
+func (n *Node) AsBool() (bool, bool) {
+	switch n.Type() {
+	case NodeNil:
+		return false, true
+	case NodeT:
+		return true, true
+	}
+	return false, false
+}
+
+// AsSymbol returns the name of a symbol or keyword.
+func (n *Node) AsSymbol() (string, bool) {
+	s, ok := symbolOf(n)
+	if !ok {
+		return "", false
+	}
+	return s.name, true
+}
+
+// AsGoValue returns the Go value wrapped by n.
+func (n *Node) AsGoValue() (interface{}, bool) {
+	if n == nil || n.t != NodeGoValue {
+		return nil, false
+	}
+	rv := n.v.(reflect.Value)
+	if !rv.IsValid() || !rv.CanInterface() {
+		return nil, true
+	}
+	return rv.Interface(), true
+}
+
+// Each calls fn for each element of the list n. It stops at the first
+// error and returns it.
+func (n *Node) Each(fn func(*Node) error) error {
+	for curr := n; curr != nil && curr.t == NodeCell; curr = curr.cdr {
+		v := curr.car
+		if v == nil {
+			if curr == n && curr.cdr == nil {
+				break
+			}
+			v = Nil()
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToSlice returns the elements of the list n.
+func (n *Node) ToSlice() []*Node {
+	var ret []*Node
+	n.Each(func(v *Node) error {
+		ret = append(ret, v)
+		return nil
+	})
+	return ret
+}
+
+// Len returns the number of elements of the list n.
+func (n *Node) Len() int {
+	l := 0
+	n.Each(func(*Node) error {
+		l++
+		return nil
+	})
+	return l
+}
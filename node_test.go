@@ -0,0 +1,75 @@
+package golisp
+
+import (
+	"testing"
+)
+
+func TestNodeAPI(t *testing.T) {
+	l := List(Int(1), Float(2.5), String("three"), Symbol("four"), Keyword("five"), Bool(true), Nil())
+	if got, want := l.String(), `(1 2.5 "three" four :five t nil)`; got != want {
+		t.Fatalf("want %q but got %q", want, got)
+	}
+	if got := l.Len(); got != 7 {
+		t.Fatalf("want 7 but got %d", got)
+	}
+
+	items := l.ToSlice()
+	if i, ok := items[0].AsInt(); !ok || i != 1 {
+		t.Errorf("want 1 but got %v", items[0])
+	}
+	if f, ok := items[0].AsFloat(); !ok || f != 1 {
+		t.Errorf("want 1.0 but got %v", items[0])
+	}
+	if f, ok := items[1].AsFloat(); !ok || f != 2.5 {
+		t.Errorf("want 2.5 but got %v", items[1])
+	}
+	if s, ok := items[2].AsString(); !ok || s != "three" {
+		t.Errorf("want three but got %v", items[2])
+	}
+	if s, ok := items[3].AsSymbol(); !ok || s != "four" {
+		t.Errorf("want four but got %v", items[3])
+	}
+	if items[3].v != Symbol("four").v {
+		t.Error("symbols should be interned")
+	}
+	if b, ok := items[5].AsBool(); !ok || !b {
+		t.Errorf("want true but got %v", items[5])
+	}
+	if !items[6].IsNil() {
+		t.Errorf("want nil but got %v", items[6])
+	}
+	if _, ok := items[2].AsInt(); ok {
+		t.Error("string should not be int")
+	}
+
+	c := Cons(Int(1), Int(2))
+	if got, want := c.String(), "(1 . 2)"; got != want {
+		t.Errorf("want %q but got %q", want, got)
+	}
+	if c.Car().Type() != NodeInt || c.Cdr().Type() != NodeInt {
+		t.Errorf("unexpected car/cdr of %v", c)
+	}
+	if !Nil().Car().IsNil() || Nil().Len() != 0 {
+		t.Error("car of nil should be nil")
+	}
+
+	env := NewEnv(nil)
+	ret, err := evalString(t, env, `(list 1 2 3)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum int64
+	err = ret.Each(func(n *Node) error {
+		i, _ := n.AsInt()
+		sum += i
+		return nil
+	})
+	if err != nil || sum != 6 {
+		t.Errorf("want 6 but got %d", sum)
+	}
+
+	v, ok := GoValue([]int{1}).AsGoValue()
+	if !ok || v.([]int)[0] != 1 {
+		t.Errorf("unexpected go value %v", v)
+	}
+}
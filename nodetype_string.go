@@ -24,11 +24,13 @@ func _() {
 	_ = x[NodeEnv-13]
 	_ = x[NodeError-14]
 	_ = x[NodeGoValue-15]
+	_ = x[NodeHash-16]
+	_ = x[NodeVector-17]
 }
 
-const _NodeType_name = "NodeNilNodeTNodeIntNodeDoubleNodeStringNodeQuoteNodeBquoteNodeIdentNodeLambdaNodeSpecialNodeBuiltinfuncNodeCellNodeArefNodeEnvNodeErrorNodeGoValue"
+const _NodeType_name = "NodeNilNodeTNodeIntNodeDoubleNodeStringNodeQuoteNodeBquoteNodeIdentNodeLambdaNodeSpecialNodeBuiltinfuncNodeCellNodeArefNodeEnvNodeErrorNodeGoValueNodeHashNodeVector"
 
-var _NodeType_index = [...]uint8{0, 7, 12, 19, 29, 39, 48, 58, 67, 77, 88, 103, 111, 119, 126, 135, 146}
+var _NodeType_index = [...]uint8{0, 7, 12, 19, 29, 39, 48, 58, 67, 77, 88, 103, 111, 119, 126, 135, 146, 154, 164}
 
 func (i NodeType) String() string {
 	if i < 0 || i >= NodeType(len(_NodeType_index)-1) {
@@ -4,15 +4,15 @@ package golisp
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"reflect"
 	"strings"
-
-	"github.com/mattn/golisp/gopkg"
-	_ "github.com/mattn/golisp/statik"
+	"sync"
 )
 
 type Ft int
@@ -29,96 +29,210 @@ type FnInfo struct {
 	fn Fn
 }
 
-var ops map[string]FnInfo
+var ops map[*symbol]FnInfo
 
 func makeFn(ft Ft, fn Fn) FnInfo {
 	return FnInfo{ft: ft, fn: fn}
 }
 
 func init() {
-	ops = make(map[string]FnInfo)
-	ops["dotimes"] = makeFn(FtSpecial, doDotimes)
-	ops["prin1"] = makeFn(FtBuiltin, doPrin1)
-	ops["print"] = makeFn(FtBuiltin, doPrint)
-	ops["princ"] = makeFn(FtBuiltin, doPrinc)
-	ops["let"] = makeFn(FtSpecial, doLet)
-	ops["let*"] = makeFn(FtSpecial, doLetStar)
-	ops["setq"] = makeFn(FtSpecial, doSetq)
-	ops["1+"] = makeFn(FtBuiltin, doPlusOne)
-	ops["1-"] = makeFn(FtBuiltin, doMinusOne)
-	ops["+"] = makeFn(FtBuiltin, doPlus)
-	ops["-"] = makeFn(FtBuiltin, doMinus)
-	ops["*"] = makeFn(FtBuiltin, doMul)
-	ops["/"] = makeFn(FtBuiltin, doDiv)
-	ops["<"] = makeFn(FtBuiltin, doLt)
-	ops["<="] = makeFn(FtBuiltin, doLe)
-	ops[">"] = makeFn(FtBuiltin, doGt)
-	ops[">="] = makeFn(FtBuiltin, doGe)
-	ops["="] = makeFn(FtBuiltin, doEqual)
-	ops["if"] = makeFn(FtSpecial, doIf)
-	ops["not"] = makeFn(FtBuiltin, doNot)
-	ops["mod"] = makeFn(FtBuiltin, doMod)
-	ops["%"] = makeFn(FtBuiltin, doMod)
-	ops["and"] = makeFn(FtSpecial, doAnd)
-	ops["or"] = makeFn(FtSpecial, doOr)
-	ops["cond"] = makeFn(FtSpecial, doCond)
-	ops["cons"] = makeFn(FtBuiltin, doCons)
-	ops["car"] = makeFn(FtBuiltin, doCar)
-	ops["cdr"] = makeFn(FtBuiltin, doCdr)
-	ops["rest"] = makeFn(FtBuiltin, doCdr)
-	ops["apply"] = makeFn(FtBuiltin, doApply)
-	ops["concatenate"] = makeFn(FtBuiltin, doConcatenate)
-	ops["defun"] = makeFn(FtSpecial, doDefun)
-	ops["float"] = makeFn(FtBuiltin, doFloat)
-	ops["while"] = makeFn(FtSpecial, doWhile)
-	ops["quote"] = makeFn(FtSpecial, doQuote)
-	ops["getenv"] = makeFn(FtBuiltin, doGetenv)
-	ops["length"] = makeFn(FtBuiltin, doLength)
-	ops["null"] = makeFn(FtBuiltin, doNull)
-	ops["list"] = makeFn(FtBuiltin, doList)
-	ops["make-string"] = makeFn(FtBuiltin, doMakeString)
-	ops["progn"] = makeFn(FtBuiltin, doProgn)
-	ops["eval"] = makeFn(FtBuiltin, doEval)
-	ops["consp"] = makeFn(FtBuiltin, doConsp)
-	ops["oddp"] = makeFn(FtBuiltin, doOddp)
-	ops["evenp"] = makeFn(FtBuiltin, doEvenp)
-	ops["load"] = makeFn(FtBuiltin, doLoad)
-	ops["funcall"] = makeFn(FtBuiltin, doFuncall)
-	ops["lambda"] = makeFn(FtSpecial, doLambda)
-	ops["type-of"] = makeFn(FtBuiltin, doTypeOf)
-	ops["labels"] = makeFn(FtSpecial, doLabels)
-	ops["flet"] = makeFn(FtSpecial, doFlet)
-	ops["rplaca"] = makeFn(FtBuiltin, doRplaca)
-	ops["rplacd"] = makeFn(FtBuiltin, doRplacd)
-	ops["nconc"] = makeFn(FtBuiltin, doNconc)
-	ops["defmacro"] = makeFn(FtSpecial, doDefmacro)
-
-	ops["go:import"] = makeFn(FtSpecial, doGoImport)
-	ops["go:make-chan"] = makeFn(FtSpecial, doGoMakeChan)
-	ops["go:chan-recv"] = makeFn(FtBuiltin, doGoChanRecv)
-	ops["go:chan-send"] = makeFn(FtBuiltin, doGoChanSend)
-	ops["go"] = makeFn(FtSpecial, doGo)
+	ops = make(map[*symbol]FnInfo)
+	ops[intern("dotimes")] = makeFn(FtSpecial, doDotimes)
+	ops[intern("prin1")] = makeFn(FtBuiltin, doPrin1)
+	ops[intern("print")] = makeFn(FtBuiltin, doPrint)
+	ops[intern("princ")] = makeFn(FtBuiltin, doPrinc)
+	ops[intern("let")] = makeFn(FtSpecial, doLet)
+	ops[intern("let*")] = makeFn(FtSpecial, doLetStar)
+	ops[intern("setq")] = makeFn(FtSpecial, doSetq)
+	ops[intern("1+")] = makeFn(FtBuiltin, doPlusOne)
+	ops[intern("1-")] = makeFn(FtBuiltin, doMinusOne)
+	ops[intern("+")] = makeFn(FtBuiltin, doPlus)
+	ops[intern("-")] = makeFn(FtBuiltin, doMinus)
+	ops[intern("*")] = makeFn(FtBuiltin, doMul)
+	ops[intern("/")] = makeFn(FtBuiltin, doDiv)
+	ops[intern("<")] = makeFn(FtBuiltin, doLt)
+	ops[intern("<=")] = makeFn(FtBuiltin, doLe)
+	ops[intern(">")] = makeFn(FtBuiltin, doGt)
+	ops[intern(">=")] = makeFn(FtBuiltin, doGe)
+	ops[intern("=")] = makeFn(FtBuiltin, doEqual)
+	ops[intern("if")] = makeFn(FtSpecial, doIf)
+	ops[intern("not")] = makeFn(FtBuiltin, doNot)
+	ops[intern("mod")] = makeFn(FtBuiltin, doMod)
+	ops[intern("%")] = makeFn(FtBuiltin, doMod)
+	ops[intern("and")] = makeFn(FtSpecial, doAnd)
+	ops[intern("or")] = makeFn(FtSpecial, doOr)
+	ops[intern("cond")] = makeFn(FtSpecial, doCond)
+	ops[intern("cons")] = makeFn(FtBuiltin, doCons)
+	ops[intern("car")] = makeFn(FtBuiltin, doCar)
+	ops[intern("cdr")] = makeFn(FtBuiltin, doCdr)
+	ops[intern("rest")] = makeFn(FtBuiltin, doCdr)
+	ops[intern("apply")] = makeFn(FtBuiltin, doApply)
+	ops[intern("concatenate")] = makeFn(FtBuiltin, doConcatenate)
+	ops[intern("defun")] = makeFn(FtSpecial, doDefun)
+	ops[intern("float")] = makeFn(FtBuiltin, doFloat)
+	ops[intern("while")] = makeFn(FtSpecial, doWhile)
+	ops[intern("quote")] = makeFn(FtSpecial, doQuote)
+	ops[intern("getenv")] = makeFn(FtBuiltin, doGetenv)
+	ops[intern("length")] = makeFn(FtBuiltin, doLength)
+	ops[intern("null")] = makeFn(FtBuiltin, doNull)
+	ops[intern("list")] = makeFn(FtBuiltin, doList)
+	ops[intern("make-string")] = makeFn(FtBuiltin, doMakeString)
+	ops[intern("progn")] = makeFn(FtBuiltin, doProgn)
+	ops[intern("eval")] = makeFn(FtBuiltin, doEval)
+	ops[intern("consp")] = makeFn(FtBuiltin, doConsp)
+	ops[intern("oddp")] = makeFn(FtBuiltin, doOddp)
+	ops[intern("evenp")] = makeFn(FtBuiltin, doEvenp)
+	ops[intern("load")] = makeFn(FtBuiltin, doLoad)
+	ops[intern("require")] = makeFn(FtBuiltin, doRequire)
+	ops[intern("provide")] = makeFn(FtBuiltin, doProvide)
+	ops[intern("funcall")] = makeFn(FtBuiltin, doFuncall)
+	ops[intern("lambda")] = makeFn(FtSpecial, doLambda)
+	ops[intern("type-of")] = makeFn(FtBuiltin, doTypeOf)
+	ops[intern("labels")] = makeFn(FtSpecial, doLabels)
+	ops[intern("flet")] = makeFn(FtSpecial, doFlet)
+	ops[intern("rplaca")] = makeFn(FtBuiltin, doRplaca)
+	ops[intern("rplacd")] = makeFn(FtBuiltin, doRplacd)
+	ops[intern("nconc")] = makeFn(FtBuiltin, doNconc)
+	ops[intern("defmacro")] = makeFn(FtSpecial, doDefmacro)
+	ops[intern("defpackage")] = makeFn(FtSpecial, doDefpackage)
+	ops[intern("in-package")] = makeFn(FtSpecial, doInPackage)
+	ops[intern("use-package")] = makeFn(FtBuiltin, doUsePackage)
+	ops[intern("setf")] = makeFn(FtSpecial, doSetf)
+	ops[intern("eq")] = makeFn(FtBuiltin, doEq)
+	ops[intern("symbolp")] = makeFn(FtBuiltin, doSymbolp)
+	ops[intern("keywordp")] = makeFn(FtBuiltin, doKeywordp)
+	ops[intern("symbol-name")] = makeFn(FtBuiltin, doSymbolName)
+	ops[intern("symbol-plist")] = makeFn(FtBuiltin, doSymbolPlist)
+	ops[intern("intern")] = makeFn(FtBuiltin, doIntern)
+	ops[intern("make-symbol")] = makeFn(FtBuiltin, doMakeSymbol)
+	ops[intern("get")] = makeFn(FtBuiltin, doGet)
+	ops[intern("make-hash-table")] = makeFn(FtBuiltin, doMakeHashTable)
+	ops[intern("gethash")] = makeFn(FtBuiltin, doGethash)
+	ops[intern("puthash")] = makeFn(FtBuiltin, doPuthash)
+	ops[intern("remhash")] = makeFn(FtBuiltin, doRemhash)
+	ops[intern("hash-table-count")] = makeFn(FtBuiltin, doHashTableCount)
+	ops[intern("hash-table-p")] = makeFn(FtBuiltin, doHashTableP)
+	ops[intern("hash-table-keys")] = makeFn(FtBuiltin, doHashTableKeys)
+	ops[intern("maphash")] = makeFn(FtBuiltin, doMaphash)
+	ops[intern("vector")] = makeFn(FtBuiltin, doVector)
+	ops[intern("vectorp")] = makeFn(FtBuiltin, doVectorP)
+	ops[intern("aref")] = makeFn(FtBuiltin, doAref)
+	ops[intern("coerce")] = makeFn(FtBuiltin, doCoerce)
+	ops[intern("error")] = makeFn(FtBuiltin, doError)
+	ops[intern("error-message")] = makeFn(FtBuiltin, doErrorMessage)
+	ops[intern("handler-case")] = makeFn(FtSpecial, doHandlerCase)
+
+	ops[intern("go:import")] = makeFn(FtSpecial, doGoImport)
+	ops[intern("go:make-chan")] = makeFn(FtSpecial, doGoMakeChan)
+	ops[intern("go:type")] = makeFn(FtSpecial, doGoType)
+	ops[intern("go:make-slice")] = makeFn(FtSpecial, doGoMakeSlice)
+	ops[intern("go:make-map")] = makeFn(FtSpecial, doGoMakeMap)
+	ops[intern("go:new")] = makeFn(FtSpecial, doGoNew)
+	ops[intern("go:zero")] = makeFn(FtSpecial, doGoZero)
+	ops[intern("go:to-list")] = makeFn(FtBuiltin, doGoToList)
+	ops[intern("go:index")] = makeFn(FtBuiltin, doGoIndex)
+	ops[intern("go:set-index")] = makeFn(FtBuiltin, doGoSetIndex)
+	ops[intern("go:len")] = makeFn(FtBuiltin, doGoLen)
+	ops[intern("go:slice")] = makeFn(FtBuiltin, doGoSlice)
+	ops[intern("go:range")] = makeFn(FtSpecial, doGoRange)
+	ops[intern("go:implement")] = makeFn(FtSpecial, doGoImplement)
+	ops[intern("defroute")] = makeFn(FtSpecial, doDefroute)
+	ops[intern("use-middleware")] = makeFn(FtBuiltin, doUseMiddleware)
+	ops[intern("http-handler")] = makeFn(FtBuiltin, doHTTPHandler)
+	ops[intern("serve")] = makeFn(FtBuiltin, doServe)
+	ops[intern("req-method")] = makeFn(FtBuiltin, doReqMethod)
+	ops[intern("req-path")] = makeFn(FtBuiltin, doReqPath)
+	ops[intern("req-param")] = makeFn(FtBuiltin, doReqParam)
+	ops[intern("req-query")] = makeFn(FtBuiltin, doReqQuery)
+	ops[intern("req-header")] = makeFn(FtBuiltin, doReqHeader)
+	ops[intern("req-body")] = makeFn(FtBuiltin, doReqBody)
+	ops[intern("req-json")] = makeFn(FtBuiltin, doReqJSON)
+	ops[intern("response")] = makeFn(FtBuiltin, doResponse)
+	ops[intern("json-response")] = makeFn(FtBuiltin, doJSONResponse)
+	ops[intern("json-parse")] = makeFn(FtBuiltin, doJSONParse)
+	ops[intern("json-stringify")] = makeFn(FtBuiltin, doJSONStringify)
+	ops[intern("http-get")] = makeFn(FtBuiltin, doHTTPGet)
+	ops[intern("http-post")] = makeFn(FtBuiltin, doHTTPPost)
+	ops[intern("http-request")] = makeFn(FtBuiltin, doHTTPRequest)
+	ops[intern("go:to-hash")] = makeFn(FtBuiltin, doGoToHash)
+	ops[intern("go:convert")] = makeFn(FtSpecial, doGoConvert)
+	ops[intern("go:chan-recv")] = makeFn(FtBuiltin, doGoChanRecv)
+	ops[intern("go:chan-send")] = makeFn(FtBuiltin, doGoChanSend)
+	ops[intern("go:select")] = makeFn(FtSpecial, doGoSelect)
+	ops[intern("go:chan-close")] = makeFn(FtBuiltin, doGoChanClose)
+	ops[intern("go:chan-len")] = makeFn(FtBuiltin, doGoChanLen)
+	ops[intern("do-chan")] = makeFn(FtSpecial, doDoChan)
+	ops[intern("go")] = makeFn(FtSpecial, doGo)
+	ops[intern("future")] = makeFn(FtSpecial, doFuture)
+	ops[intern("await")] = makeFn(FtBuiltin, doAwait)
+	ops[intern("pmap")] = makeFn(FtBuiltin, doPmap)
+	ops[intern("with-task-group")] = makeFn(FtSpecial, doWithTaskGroup)
 }
 
 type Env struct {
-	vars map[string]*Node
-	fncs map[string]*Node
-	mcrs map[string]*Node
+	// mu guards vars, fncs, and for the root ops, pkgs, features, goPkgs,
+	// goTypes, adapters and routes, so that goroutines started with go can
+	// share bindings.
+	mu   sync.RWMutex
+	vars map[*symbol]*Node
+	fncs map[*symbol]*Node
+	ops  map[*symbol]FnInfo
+	pkgs map[string]*Package
+	pkg  *Package
+	ctx  context.Context
 	env  *Env
 	out  io.Writer
+
+	fsys     fs.FS
+	lib      fs.FS
+	libDir   string
+	file     *source  // file evaluated in this frame
+	top      bool     // frame of an evaluation started with EvalContext
+	loading  []string // modules being required in this frame
+	features map[string]bool
+	sandbox  *sandbox
+	usage    *usage // sandbox limits used by the evaluation in this frame
+	depth    int
+	group    *taskGroup
+	onError  func(error)
+	goPkgs   map[string]map[string]reflect.Value
+	goTypes  map[string]map[string]reflect.Type
+	adapters map[reflect.Type]Adapter
+	routes   *router
 }
 
 func NewEnv(env *Env) *Env {
-	var out io.Writer = os.Stdout
-	if env != nil {
-		out = env.out
+	if env == nil {
+		pkg := newPackage(DefaultPackage)
+		fns := make(map[*symbol]FnInfo, len(ops))
+		for k, v := range ops {
+			fns[k] = v
+		}
+		return &Env{
+			vars: map[*symbol]*Node{
+				symLoadPath: loadPath(),
+			},
+			fncs:     make(map[*symbol]*Node),
+			ops:      fns,
+			pkgs:     map[string]*Package{pkg.name: pkg},
+			pkg:      pkg,
+			ctx:      context.Background(),
+			out:      os.Stdout,
+			lib:      lib,
+			libDir:   "lib",
+			features: make(map[string]bool),
+		}
 	}
 	return &Env{
-		vars: make(map[string]*Node),
-		fncs: make(map[string]*Node),
-		mcrs: make(map[string]*Node),
-		env:  env,
-		out:  out,
+		vars:  make(map[*symbol]*Node),
+		fncs:  make(map[*symbol]*Node),
+		pkg:   env.pkg,
+		ctx:   env.ctx,
+		env:   env,
+		out:   env.out,
+		depth: env.depth,
+		group: env.group,
+		usage: env.usage,
 	}
 }
 
@@ -126,6 +240,93 @@ func (e *Env) SetOut(o io.Writer) {
 	e.out = o
 }
 
+// op returns the builtin named name in the interpreter of e.
+func (e *Env) op(name *symbol) (FnInfo, bool) {
+	root := e.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	ft, ok := root.ops[name]
+	return ft, ok
+}
+
+func (e *Env) setOp(name *symbol, ft FnInfo) {
+	root := e.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.ops[name] = ft
+}
+
+// getVar returns the variable bound to name in the frame e, not looking
+// at its parents.
+func (e *Env) getVar(name *symbol) (*Node, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	v, ok := e.vars[name]
+	return v, ok
+}
+
+// lookupVar returns the variable bound to name in e or its parents.
+func (e *Env) lookupVar(name *symbol) (*Node, bool) {
+	for ; e != nil; e = e.env {
+		if v, ok := e.getVar(name); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// putVar binds name to value in the frame e.
+func (e *Env) putVar(name *symbol, value *Node) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.vars[name] = value
+}
+
+// replaceVar rebinds name to value if it is bound in the frame e.
+func (e *Env) replaceVar(name *symbol, value *Node) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.vars[name]; !ok {
+		return false
+	}
+	e.vars[name] = value
+	return true
+}
+
+// getFn returns the local function bound to name in the frame e.
+func (e *Env) getFn(name *symbol) (*Node, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	v, ok := e.fncs[name]
+	return v, ok
+}
+
+func (e *Env) putFn(name *symbol, fn *Node) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fncs[name] = fn
+}
+
+// SetGoErrorHandler sets the function called with the error of each
+// goroutine started with go that fails. The handler may be called from
+// several goroutines at once.
+func (e *Env) SetGoErrorHandler(fn func(error)) {
+	root := e.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.onError = fn
+}
+
+func (e *Env) goError(err error) {
+	root := e.root()
+	root.mu.RLock()
+	fn := root.onError
+	root.mu.RUnlock()
+	if fn != nil {
+		fn(err)
+	}
+}
+
 func (n *Node) CarIsNil() bool {
 	return n.car == nil || n.car.t == NodeNil
 }
@@ -135,10 +336,14 @@ func (n *Node) CdrIsNil() bool {
 }
 
 func (e *Env) Eval(node *Node) (*Node, error) {
+	return e.EvalContext(context.Background(), node)
+}
+
+func evalForms(env *Env, node *Node) (*Node, error) {
 	var ret *Node
 	var err error
 	for node != nil && node.car != nil {
-		ret, err = eval(e, node.car)
+		ret, err = eval(env, node.car)
 		if err != nil {
 			return nil, err
 		}
@@ -160,6 +365,9 @@ func evalList(env *Env, node *Node) (*Node, error) {
 				t: NodeNil,
 			}
 		}
+		if err := env.alloc(1); err != nil {
+			return nil, err
+		}
 		vvv := &Node{
 			t:   NodeCell,
 			car: vv,
@@ -182,51 +390,48 @@ func evalList(env *Env, node *Node) (*Node, error) {
 
 func call(env *Env, node *Node) (*Node, error) {
 	var macro bool
+	var pkg *Package
 
 	if node.car != nil && node.car.t == NodeIdent {
-		name := node.car.v.(string)
-		if ft, ok := ops[name]; ok {
+		name := node.car.v.(*symbol)
+		if ft, ok := env.op(name); ok {
 			return ft.fn(env, node.cdr)
 		}
 		e := env
 		var fn, alist *Node
 		var ok bool
 		for e != nil {
-			fn, ok = e.fncs[name]
+			fn, ok = e.getFn(name)
 			if ok {
 				alist = node.cdr
 				break
 			}
-			fn, ok = e.vars[name]
+			fn, ok = e.getVar(name)
 			if ok && fn.t == NodeLambda {
 				alist = node.cdr
 				break
 			}
+			fn = nil
 			e = e.env
 		}
 		if fn == nil {
-			global := env
-			for global.env != nil {
-				global = global.env
-			}
-			fn, ok = global.mcrs[name]
-			if ok {
-				alist = node.cdr
-				macro = true
+			var err error
+			fn, pkg, macro, err = lookupFunction(env, name)
+			if err != nil {
+				return nil, err
 			}
 			if fn == nil {
 				return nil, fmt.Errorf("invalid op: %v", name)
 			}
+			alist = node.cdr
 		}
 		if !macro && fn.t != NodeLambda {
-			ev := &Node{
+			node = &Node{
 				t:   NodeCell,
 				car: fn,
 				cdr: alist,
 			}
-			return eval(env, ev)
-		}
-		if macro {
+		} else if macro {
 			node = &Node{
 				t: NodeCell,
 				car: &Node{
@@ -238,6 +443,9 @@ func call(env *Env, node *Node) (*Node, error) {
 				cdr: node.cdr,
 			}
 		} else {
+			if p, ok := fn.v.(*Package); ok && pkg == nil {
+				pkg = p
+			}
 			node = &Node{
 				t: NodeCell,
 				car: &Node{
@@ -250,6 +458,7 @@ func call(env *Env, node *Node) (*Node, error) {
 			}
 		}
 	} else if node.car != nil && node.car.t == NodeLambda {
+		pkg, _ = node.car.v.(*Package)
 		node = &Node{
 			t: NodeCell,
 			car: &Node{
@@ -266,23 +475,34 @@ func call(env *Env, node *Node) (*Node, error) {
 		return node, nil
 	}
 
+	if err := env.enter(); err != nil {
+		return nil, err
+	}
 	scope := NewEnv(node.car.e)
+	scope.ctx = env.ctx
+	scope.out = env.out
+	scope.usage = env.usage
+	scope.depth = env.depth + 1
+	scope.group = env.group
+	if pkg != nil {
+		scope.pkg = pkg
+	}
 	var code *Node
 	if node.car.cdr.car != nil {
 		arg := node.car.cdr.car
 		val := node.cdr
 		for arg != nil && arg.t != NodeNil {
-			var name string
+			var name *symbol
 			if arg.car != nil {
-				name = arg.car.v.(string)
+				name = arg.car.v.(*symbol)
 			} else {
-				name = arg.v.(string)
+				name = arg.v.(*symbol)
 			}
 			var vv *Node
 			var err error
-			if name == "&rest" {
+			if name == symRest {
 				arg = arg.cdr
-				name = arg.car.v.(string)
+				name = arg.car.v.(*symbol)
 				vv, err = evalList(env, val)
 			} else if macro {
 				vv, err = val.car, nil
@@ -294,7 +514,7 @@ func call(env *Env, node *Node) (*Node, error) {
 			if err != nil {
 				return nil, err
 			}
-			scope.vars[name] = vv
+			scope.putVar(name, vv)
 			arg = arg.cdr
 			val = val.cdr
 		}
@@ -320,32 +540,32 @@ func call(env *Env, node *Node) (*Node, error) {
 }
 
 func eval(env *Env, node *Node) (*Node, error) {
+	if err := env.step(); err != nil {
+		return nil, err
+	}
 	var ret *Node
 	switch node.t {
 	case NodeLambda:
 		return call(env, node)
 	case NodeIdent:
-		name := node.v.(string)
-		_, ok := ops[name]
+		name := node.v.(*symbol)
+		if name.IsKeyword() {
+			return node, nil
+		}
+		_, ok := env.op(name)
 		if ok {
 			return node, nil
 		}
 
-		e := env
-		for e != nil {
-			v, ok := e.vars[name]
-			if ok {
-				return v, nil
-			}
-			e = e.env
+		if v, ok := env.lookupVar(name); ok {
+			return v, nil
 		}
 
-		e = env
-		for e.env != nil {
-			e = e.env
+		v, _, macro, err := lookupFunction(env, name)
+		if err != nil {
+			return nil, err
 		}
-		v, ok := e.fncs[name]
-		if ok {
+		if v != nil && !macro {
 			return v, nil
 		}
 
@@ -358,7 +578,7 @@ func eval(env *Env, node *Node) (*Node, error) {
 			}, nil
 		}
 		if node.car.t == NodeIdent {
-			ft, ok := ops[node.car.v.(string)]
+			ft, ok := env.op(node.car.v.(*symbol))
 			if ok {
 				if ft.ft == FtBuiltin {
 					alist, err := evalList(env, node.cdr)
@@ -375,7 +595,7 @@ func eval(env *Env, node *Node) (*Node, error) {
 				}
 				return ft.fn(env, alist)
 			}
-			fn := node.car.v.(string)
+			fn := node.car.v.(*symbol).name
 			if fn[0] == '.' && node.cdr != nil {
 				if len(fn) == 1 {
 					return doGoField(env, node)
@@ -384,11 +604,12 @@ func eval(env *Env, node *Node) (*Node, error) {
 			}
 		}
 		if node.car.t == NodeCell {
-			if node.car.car != nil && node.car.car.t == NodeIdent && node.car.car.v.(string) == "lambda" {
+			if node.car.car != nil && node.car.car.t == NodeIdent && node.car.car.v.(*symbol) == symLambda {
 				node = &Node{
 					t: NodeCell,
 					car: &Node{
 						t:   NodeEnv,
+						e:   env,
 						car: node.car.car,
 						cdr: node.car.cdr,
 					},
@@ -413,8 +634,10 @@ func doPrin1(env *Env, node *Node) (*Node, error) {
 	}
 	if node.car.t == NodeNil {
 		fmt.Fprint(env.out, "nil")
+	} else if node.car.t == NodeVector {
+		fmt.Fprint(env.out, node.car)
 	} else {
-		fmt.Fprintln(env.out, node.car.v)
+		fmt.Fprint(env.out, node.car.v)
 	}
 	return node.car, nil
 }
@@ -429,7 +652,7 @@ func doPrint(env *Env, node *Node) (*Node, error) {
 		fmt.Fprintln(env.out, "t")
 	} else if node.car.t == NodeQuote {
 		fmt.Fprintln(env.out, node.car)
-	} else if node.car.t == NodeCell {
+	} else if node.car.t == NodeCell || node.car.t == NodeVector {
 		fmt.Fprintln(env.out, node.car)
 	} else {
 		fmt.Fprintln(env.out, node.car.v)
@@ -447,7 +670,7 @@ func doPrinc(env *Env, node *Node) (*Node, error) {
 		fmt.Fprint(env.out, "t")
 	} else if node.car.t == NodeQuote {
 		fmt.Fprint(env.out, node.car)
-	} else if node.car.t == NodeCell {
+	} else if node.car.t == NodeCell || node.car.t == NodeVector {
 		fmt.Fprint(env.out, node.car)
 	} else {
 		fmt.Fprint(env.out, node.car.v)
@@ -464,7 +687,7 @@ func doDotimes(env *Env, node *Node) (*Node, error) {
 	if node.car == nil || node.car.cdr == nil || node.car.cdr.car == nil {
 		return nil, errors.New("invalid arguments for dotimes")
 	}
-	v := node.car.car.v.(string)
+	v := node.car.car.v.(*symbol)
 	count, err := eval(env, node.car.cdr.car)
 	if err != nil {
 		return nil, err
@@ -476,7 +699,7 @@ func doDotimes(env *Env, node *Node) (*Node, error) {
 		t: NodeInt,
 		v: int64(0),
 	}
-	scope.vars[v] = vv
+	scope.putVar(v, vv)
 
 	cond := node.cdr
 	var i int64
@@ -485,7 +708,7 @@ func doDotimes(env *Env, node *Node) (*Node, error) {
 			t: NodeInt,
 			v: int64(i),
 		}
-		scope.vars[v] = vv
+		scope.putVar(v, vv)
 		if cond != nil {
 			curr := cond
 			for curr != nil {
@@ -501,7 +724,7 @@ func doDotimes(env *Env, node *Node) (*Node, error) {
 		t: NodeInt,
 		v: int64(i),
 	}
-	scope.vars[v] = vv
+	scope.putVar(v, vv)
 
 	if node.car.cdr.cdr != nil {
 		return eval(scope, node.car.cdr.cdr.car)
@@ -528,9 +751,9 @@ func doLet(env *Env, node *Node) (*Node, error) {
 	curr := node.car
 	for curr != nil {
 		if curr.car.cdr == nil {
-			scope.vars[curr.car.v.(string)] = &Node{
+			scope.putVar(curr.car.v.(*symbol), &Node{
 				t: NodeNil,
-			}
+			})
 		} else {
 			vv, err = eval(env, curr.car.cdr.car)
 			if err != nil {
@@ -538,17 +761,11 @@ func doLet(env *Env, node *Node) (*Node, error) {
 			}
 			switch curr.car.car.t {
 			case NodeCell:
-				obj, err := eval(env, curr.car.car.cdr.car)
-				if err != nil {
+				if err := setField(env, curr.car.car, vv); err != nil {
 					return nil, err
 				}
-				if obj.t == NodeCell && obj.car != nil && obj.car.t == NodeGoValue {
-					obj = obj.car
-				}
-				fld := obj.v.(reflect.Value).Elem().FieldByName(curr.car.car.cdr.cdr.car.v.(string))
-				fld.Set(vv.car.v.(reflect.Value))
 			case NodeIdent:
-				scope.vars[curr.car.car.v.(string)] = vv
+				scope.putVar(curr.car.car.v.(*symbol), vv)
 			}
 		}
 		curr = curr.cdr
@@ -581,15 +798,15 @@ func doLetStar(env *Env, node *Node) (*Node, error) {
 	curr := node.car
 	for curr != nil {
 		if curr.car.cdr == nil {
-			scope.vars[curr.car.v.(string)] = &Node{
+			scope.putVar(curr.car.v.(*symbol), &Node{
 				t: NodeNil,
-			}
+			})
 		} else {
 			vv, err = eval(env, curr.car.cdr.car)
 			if err != nil {
 				return nil, err
 			}
-			scope.vars[curr.car.car.v.(string)] = vv
+			scope.putVar(curr.car.car.v.(*symbol), vv)
 		}
 		env = scope
 		scope = NewEnv(env)
@@ -619,28 +836,33 @@ func doSetq(env *Env, node *Node) (*Node, error) {
 	var err error
 	curr := node
 	for curr != nil {
-		name := curr.car.v.(string)
+		name := curr.car.v.(*symbol)
 		ret, err = eval(env, curr.cdr.car)
 		if err != nil {
 			return nil, err
 		}
-		e := env
-		for e != nil {
-			_, ok := e.vars[name]
-			if ok {
-				e.vars[name] = ret
-				break
-			}
-			e = e.env
-		}
-		if e == nil {
-			env.vars[name] = ret
-		}
+		setVar(env, name, ret)
 		curr = curr.cdr.cdr
 	}
 	return ret, nil
 }
 
+func setVar(env *Env, name *symbol, value *Node) {
+	e := env
+	for e != nil {
+		if e.replaceVar(name, value) {
+			return
+		}
+		e = e.env
+	}
+	// Top-level variables of a loaded file or of an evaluation are bound
+	// outside of its frame.
+	for (env.file != nil || env.top) && env.env != nil {
+		env = env.env
+	}
+	env.putVar(name, value)
+}
+
 func doPlusOne(env *Env, node *Node) (*Node, error) {
 	if node.car == nil || (node.car.t != NodeInt && node.car.t != NodeDouble) {
 		return nil, errors.New("invalid arguments for 1+")
@@ -1248,6 +1470,9 @@ func doCons(env *Env, node *Node) (*Node, error) {
 	var rhs *Node
 	lhs := node.car
 	rhs = node.cdr.car
+	if err := env.alloc(1); err != nil {
+		return nil, err
+	}
 	return &Node{
 		t:   NodeCell,
 		car: lhs,
@@ -1260,7 +1485,7 @@ func doCar(env *Env, node *Node) (*Node, error) {
 	if curr.t == NodeQuote {
 		return &Node{
 			t: NodeIdent,
-			v: "quote",
+			v: symQuote,
 		}, nil
 	}
 	if curr.t != NodeCell && curr.t != NodeNil {
@@ -1308,7 +1533,7 @@ func doFirst(env *Env, node *Node) (*Node, error) {
 	if node.car.t == NodeQuote {
 		return &Node{
 			t: NodeIdent,
-			v: "quote",
+			v: symQuote,
 		}, nil
 	}
 	return node.car.car, nil
@@ -1323,7 +1548,7 @@ func doSecond(env *Env, node *Node) (*Node, error) {
 	if node.car.t == NodeQuote {
 		return &Node{
 			t: NodeIdent,
-			v: "quote",
+			v: symQuote,
 		}, nil
 	}
 	return node.car.car.car, nil
@@ -1338,7 +1563,7 @@ func doThird(env *Env, node *Node) (*Node, error) {
 	if node.car.t == NodeQuote {
 		return &Node{
 			t: NodeIdent,
-			v: "quote",
+			v: symQuote,
 		}, nil
 	}
 	return node.car.car.car.car, nil
@@ -1382,13 +1607,6 @@ func doApply(env *Env, node *Node) (*Node, error) {
 	return call(env, vv)
 }
 
-func doAref(env *Env, node *Node) (*Node, error) {
-	return &Node{
-		t:   NodeAref,
-		car: node.car,
-	}, nil
-}
-
 func doConcatenate(env *Env, node *Node) (*Node, error) {
 	if node.car == nil || node.car.t != NodeIdent {
 		return nil, errors.New("invalid arguments for concatenate")
@@ -1466,12 +1684,7 @@ func doDefun(env *Env, node *Node) (*Node, error) {
 	}
 	v.cdr = node.cdr
 
-	global := env
-	for global.env != nil {
-		global = global.env
-	}
-
-	global.fncs[node.car.v.(string)] = v
+	env.pkg.defun(node.car.v.(*symbol), v)
 	return v, nil
 }
 
@@ -1507,6 +1720,8 @@ func doLength(env *Env, node *Node) (*Node, error) {
 			l++
 			curr = curr.cdr
 		}
+	case NodeVector:
+		l = int64(len(node.car.v.([]*Node)))
 	case NodeNil:
 		l = 0
 	}
@@ -1591,16 +1806,7 @@ func doLoad(env *Env, node *Node) (*Node, error) {
 		return nil, errors.New("invalid arguments for load")
 	}
 
-	f, err := os.Open(node.car.v.(string))
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	curr, err := NewParser(f).Parse()
-	if err != nil {
-		return nil, err
-	}
-	return env.Eval(curr)
+	return loadSource(env, openSource(env, node.car.v.(string)), loadingModules(env))
 }
 
 func doFuncall(env *Env, node *Node) (*Node, error) {
@@ -1621,6 +1827,7 @@ func doLambda(env *Env, node *Node) (*Node, error) {
 	}
 	return &Node{
 		t:   NodeLambda,
+		v:   env.pkg,
 		e:   env,
 		car: node.car,
 		cdr: node.cdr,
@@ -1706,12 +1913,19 @@ func doTypeOf(env *Env, node *Node) (*Node, error) {
 		t = "function"
 	case NodeIdent:
 		t = "symbol"
+		if curr.v.(*symbol).IsKeyword() {
+			t = "keyword"
+		}
 	case NodeEnv:
 		t = "environment"
 	case NodeGoValue:
 		t = "go:" + reflect.TypeOf(curr.v).String()
 	case NodeError:
 		t = "error"
+	case NodeHash:
+		t = "hash-table"
+	case NodeVector:
+		t = "vector"
 	}
 
 	return &Node{
@@ -1735,7 +1949,7 @@ func doLabels(env *Env, node *Node) (*Node, error) {
 		vv := &Node{
 			t:   NodeEnv,
 			e:   scope,
-			v:   curr.car.car.v.(string),
+			v:   curr.car.car.v.(*symbol),
 			car: curr.car.cdr.car,
 			cdr: curr.car.cdr.cdr,
 		}
@@ -1747,7 +1961,7 @@ func doLabels(env *Env, node *Node) (*Node, error) {
 			cdr: vv.cdr,
 		}
 
-		scope.fncs[curr.car.car.v.(string)] = nn
+		scope.putFn(curr.car.car.v.(*symbol), nn)
 		curr = curr.cdr
 	}
 
@@ -1769,7 +1983,7 @@ func doFlet(env *Env, node *Node) (*Node, error) {
 		vv := &Node{
 			t:   NodeEnv,
 			e:   env,
-			v:   curr.car.car.v.(string),
+			v:   curr.car.car.v.(*symbol),
 			car: curr.car.cdr.car,
 			cdr: curr.car.cdr.cdr,
 		}
@@ -1781,7 +1995,7 @@ func doFlet(env *Env, node *Node) (*Node, error) {
 			cdr: vv.cdr,
 		}
 
-		scope.fncs[curr.car.car.v.(string)] = nn
+		scope.putFn(curr.car.car.v.(*symbol), nn)
 		curr = curr.cdr
 	}
 
@@ -1807,16 +2021,16 @@ func doBquote(env *Env, node *Node) (*Node, error) {
 		var ok bool
 		var err error
 		if bq && curr.car.t == NodeIdent {
-			name := curr.car.v.(string)
-			if strings.HasPrefix(name, "@") {
+			name := curr.car.v.(*symbol)
+			if strings.HasPrefix(name.name, "@") {
 				expand = 2
-				v, ok = env.vars[name[1:]]
+				v, ok = env.lookupVar(intern(name.name[1:]))
 				if !ok {
 					return nil, errors.New("invalid arguments for bquote")
 				}
 			} else {
 				expand = 1
-				v, ok = env.vars[name]
+				v, ok = env.lookupVar(name)
 				if !ok {
 					return nil, errors.New("invalid arguments for bquote")
 				}
@@ -1917,28 +2131,27 @@ func doDefmacro(env *Env, node *Node) (*Node, error) {
 	}
 	nn := &Node{
 		t:   NodeLambda,
+		v:   env.pkg,
 		e:   env,
 		car: node.car,
 		cdr: node.cdr,
 	}
 
-	global := env
-	for global.env != nil {
-		global = global.env
-	}
-
-	global.mcrs[node.car.v.(string)] = nn
+	env.pkg.defmacro(node.car.v.(*symbol), nn)
 
 	return nn, nil
 }
 
+// doGoMethodCall calls a Go function or method with (.Name value args...).
+// It returns the list of all the results, even a single one, as scripts take
+// them with car. A non-nil trailing error is returned as error.
 func doGoMethodCall(env *Env, node *Node) (rret *Node, rerr error) {
 	defer func() {
 		if err := recover(); err != nil {
 			rerr = errors.New(fmt.Sprint(err))
 		}
 	}()
-	name := node.car.v.(string)[1:]
+	name := node.car.v.(*symbol).name[1:]
 	obj, err := eval(env, node.cdr.car)
 	if err != nil {
 		return nil, err
@@ -1947,60 +2160,44 @@ func doGoMethodCall(env *Env, node *Node) (rret *Node, rerr error) {
 		obj = obj.car
 	}
 
-	var rv reflect.Value
-	var rrv []reflect.Value
+	var fn reflect.Value
 	pkg, ok := obj.v.(map[string]reflect.Value)
 	if ok {
-		rv, ok := pkg[name]
+		fn, ok = pkg[name]
 		if !ok {
 			return nil, fmt.Errorf("invalid symbol name: %v", name)
 		}
-		rt := rv.Type()
-		numIn := rt.NumIn()
-
-		curr := node.cdr.cdr
-		args := []reflect.Value{}
-		in := 0
-		for curr != nil && curr.car != nil {
-			arg, err := eval(env, curr.car)
-			if err != nil {
-				return nil, err
-			}
-			if arg.t == NodeCell && arg.car != nil && arg.car.t == NodeGoValue {
-				arg = arg.car
-			}
-			var rav reflect.Value
-			at := rt.In(in)
-			if arg.t == NodeGoValue {
-				rav = arg.v.(reflect.Value)
-				args = append(args, rav.Convert(at))
-			} else if arg.v == nil {
-				args = append(args, reflect.Zero(at))
-			} else {
-				rav = reflect.ValueOf(arg.v)
-				args = append(args, rav.Convert(at))
-			}
-			curr = curr.cdr
-
-			in++
-			if in == numIn {
-				break
-			}
-		}
-		rrv = rv.Call(args)
 	} else {
-		rv, ok = obj.v.(reflect.Value)
+		rv, ok := obj.v.(reflect.Value)
 		if !ok {
 			rv = reflect.ValueOf(obj.v)
 		}
-		method := rv.MethodByName(name)
-		curr := node.cdr.cdr
-		args := []reflect.Value{}
-		for curr != nil {
-			args = append(args, reflect.ValueOf(curr.car))
-			curr = curr.cdr
+		fn = methodByName(rv, name)
+		if !fn.IsValid() {
+			return nil, fmt.Errorf("invalid method name: %v", name)
+		}
+	}
+	if fn.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%v is not a function", name)
+	}
+
+	alist, err := evalList(env, node.cdr.cdr)
+	if err != nil {
+		return nil, err
+	}
+	call := &goCallErrors{}
+	args, err := goArgs(env, fn.Type(), alist, call)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	rrv := fn.Call(args)
+	if err := call.finish(); err != nil {
+		return nil, err
+	}
+	if n := len(rrv); n > 0 && fn.Type().Out(n-1) == errorType {
+		if err, _ := rrv[n-1].Interface().(error); err != nil {
+			return nil, err
 		}
-		rrv = method.Call(args)
 	}
 
 	rets := &Node{
@@ -2010,19 +2207,41 @@ func doGoMethodCall(env *Env, node *Node) (rret *Node, rerr error) {
 	head := rets
 	for _, ret := range rrv {
 		x := &Node{
-			t: NodeCell,
-			car: &Node{
-				t: NodeGoValue,
-				v: ret,
-			},
+			t:   NodeCell,
+			car: valueToNode(ret),
 		}
 		rets.cdr = x
 		rets = x
 	}
+	if head.cdr == nil {
+		return &Node{
+			t: NodeNil,
+		}, nil
+	}
 
 	return head.cdr, nil
 }
 
+// methodByName returns the method name of rv, looking through pointers and
+// taking the address of addressable values for pointer methods.
+func methodByName(rv reflect.Value, name string) reflect.Value {
+	if !rv.IsValid() {
+		return reflect.Value{}
+	}
+	if m := rv.MethodByName(name); m.IsValid() {
+		return m
+	}
+	if rv.CanAddr() {
+		if m := rv.Addr().MethodByName(name); m.IsValid() {
+			return m
+		}
+	}
+	if (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && !rv.IsNil() {
+		return methodByName(rv.Elem(), name)
+	}
+	return reflect.Value{}
+}
+
 func doGoField(env *Env, node *Node) (rret *Node, rerr error) {
 	if node.car == nil || node.cdr == nil || node.cdr.cdr == nil || node.cdr.cdr.car == nil {
 		return nil, errors.New("invalid arguments for .")
@@ -2051,19 +2270,15 @@ func doGoField(env *Env, node *Node) (rret *Node, rerr error) {
 			return nil, fmt.Errorf("invalid symbol name: %v", name)
 		}
 	} else {
-		rv, ok = obj.v.(reflect.Value)
-		if !ok {
-			rv = reflect.ValueOf(obj.v)
+		rv, err = goField(obj, name)
+		if err != nil {
+			return nil, err
 		}
-		rv = rv.FieldByName(name)
 	}
 
 	return &Node{
-		t: NodeCell,
-		car: &Node{
-			t: NodeGoValue,
-			v: rv,
-		},
+		t:   NodeCell,
+		car: valueToNode(rv),
 	}, nil
 }
 
@@ -2074,7 +2289,10 @@ func doGoImport(env *Env, node *Node) (*Node, error) {
 	} else {
 		name = fmt.Sprint(node.car.v)
 	}
-	pkg, ok := gopkg.Packages[name]
+	if err := env.allowPackage(name); err != nil {
+		return nil, err
+	}
+	pkg, ok := env.goPackage(name)
 	if !ok {
 		return nil, fmt.Errorf("invalid package name: %v", name)
 	}
@@ -2085,17 +2303,19 @@ func doGoImport(env *Env, node *Node) (*Node, error) {
 }
 
 func doGoMakeChan(env *Env, node *Node) (*Node, error) {
-	name := fmt.Sprint(node.car.v)
-	typ, ok := gopkg.BasicTypes[name]
-	if !ok {
-		return nil, fmt.Errorf("invalid type name: %v", name)
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for go:make-chan")
+	}
+	typ, err := goType(env, node.car)
+	if err != nil {
+		return nil, err
 	}
 	size := 0
-	if node.car.car != nil {
-		if node.car.car.t != NodeInt {
-			return nil, fmt.Errorf("invalid size name: %v", node.car.car.v)
+	if node.cdr != nil && node.cdr.car != nil {
+		size, err = intArg(env, node.cdr.car, "go:make-chan")
+		if err != nil {
+			return nil, err
 		}
-		size = int(node.car.car.v.(int64))
 	}
 	return &Node{
 		t: NodeGoValue,
@@ -2104,33 +2324,31 @@ func doGoMakeChan(env *Env, node *Node) (*Node, error) {
 }
 
 func doGoChanSend(env *Env, node *Node) (rret *Node, rerr error) {
-	/*
-		defer func() {
-			if err := recover(); err != nil {
-				rerr = errors.New(fmt.Sprint(err))
-			}
-		}()
-	*/
+	defer func() {
+		if err := recover(); err != nil {
+			rerr = errors.New(fmt.Sprint(err))
+		}
+	}()
 	if node.car == nil || node.cdr == nil || node.cdr.car == nil || node.car.t != NodeGoValue {
-		fmt.Println(node.car)
-		fmt.Println(node.car.cdr)
-		fmt.Println(node.car.cdr.car)
-		fmt.Println(node.car.t)
 		return nil, errors.New("invalid arguments for go:chan-send")
 	}
 
 	ch := node.car.v.(reflect.Value)
 
-	var rv reflect.Value
-	if node.cdr.car.t == NodeGoValue {
-		rv = node.car.car.v.(reflect.Value)
-	} else {
-		rv = reflect.ValueOf(node.cdr.car.v)
+	rv, err := nodeToValue(node.cdr.car, ch.Type().Elem())
+	if err != nil {
+		return nil, err
 	}
 
-	ch.Send(rv)
+	chosen, _, _ := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: ch, Send: rv},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(env.done())},
+	})
+	if chosen == 1 {
+		return nil, env.canceled()
+	}
 
-	return node.car.car, nil
+	return node.cdr.car, nil
 }
 
 func doGoChanRecv(env *Env, node *Node) (rret *Node, rerr error) {
@@ -2145,7 +2363,13 @@ func doGoChanRecv(env *Env, node *Node) (rret *Node, rerr error) {
 
 	ch := node.car.v.(reflect.Value)
 
-	rv, ok := ch.Recv()
+	chosen, rv, ok := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(env.done())},
+	})
+	if chosen == 1 {
+		return nil, env.canceled()
+	}
 
 	var res *Node
 	if ok {
@@ -2160,11 +2384,8 @@ func doGoChanRecv(env *Env, node *Node) (rret *Node, rerr error) {
 	}
 
 	return &Node{
-		t: NodeCell,
-		car: &Node{
-			t: NodeGoValue,
-			v: rv,
-		},
+		t:   NodeCell,
+		car: valueToNode(rv),
 		cdr: res,
 	}, nil
 }
@@ -2179,22 +2400,13 @@ func doGo(env *Env, node *Node) (rret *Node, rerr error) {
 		return nil, errors.New("invalid arguments for go")
 	}
 
-	go func(env *Env) {
-		defer func() {
-			recover()
-		}()
-		curr := node
-		for curr != nil {
-			_, err := eval(env, curr.car)
-			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
-				return
-			}
-			curr = curr.cdr
-		}
-	}(env)
+	errc := make(chan error, 1)
+	spawn(env, true, errc, func(env *Env) (*Node, error) {
+		return evalForms(env, node)
+	})
 
 	return &Node{
-		t: NodeNil,
+		t: NodeGoValue,
+		v: reflect.ValueOf(errc),
 	}, nil
 }
@@ -0,0 +1,285 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Package is a namespace for functions and macros defined with defun and
+// defmacro. Each root Env has its own set of packages, and starts in the
+// package named by DefaultPackage.
+type Package struct {
+	mu      sync.RWMutex
+	name    string
+	fncs    map[*symbol]*Node
+	mcrs    map[*symbol]*Node
+	exports map[*symbol]bool
+	uses    []*Package
+}
+
+// DefaultPackage is the name of the package that code runs in unless it
+// calls in-package. Definitions in it are visible from every package.
+const DefaultPackage = "user"
+
+func newPackage(name string) *Package {
+	return &Package{
+		name:    name,
+		fncs:    make(map[*symbol]*Node),
+		mcrs:    make(map[*symbol]*Node),
+		exports: make(map[*symbol]bool),
+	}
+}
+
+func (p *Package) Name() string {
+	return p.name
+}
+
+func (p *Package) String() string {
+	return p.name
+}
+
+// lookup returns the function or macro bound to name in p.
+func (p *Package) lookup(name *symbol) (*Node, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if fn, ok := p.fncs[name]; ok {
+		return fn, false
+	}
+	if fn, ok := p.mcrs[name]; ok {
+		return fn, true
+	}
+	return nil, false
+}
+
+func (p *Package) defun(name *symbol, fn *Node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fncs[name] = fn
+}
+
+func (p *Package) defmacro(name *symbol, fn *Node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mcrs[name] = fn
+}
+
+func (p *Package) exported(name *symbol) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.exports[name]
+}
+
+func (p *Package) export(name *symbol) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.exports[name] = true
+}
+
+// used returns the packages used by p.
+func (p *Package) used() []*Package {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.uses
+}
+
+func (e *Env) root() *Env {
+	for e.env != nil {
+		e = e.env
+	}
+	return e
+}
+
+// Package returns the package that code evaluated in e belongs to.
+func (e *Env) Package() *Package {
+	return e.pkg
+}
+
+// FindPackage returns the package with the given name.
+func (e *Env) FindPackage(name string) (*Package, bool) {
+	root := e.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	p, ok := root.pkgs[name]
+	return p, ok
+}
+
+// splitQualified splits pkg:sym or pkg::sym. Names starting with go: are
+// builtins, not package references.
+func splitQualified(name *symbol) (string, *symbol, bool, bool) {
+	s := name.name
+	i := strings.IndexByte(s, ':')
+	if i <= 0 || i == len(s)-1 || s[:i] == "go" {
+		return "", nil, false, false
+	}
+	if s[i+1] == ':' {
+		if i+2 == len(s) {
+			return "", nil, false, false
+		}
+		return s[:i], intern(s[i+2:]), true, true
+	}
+	return s[:i], intern(s[i+1:]), false, true
+}
+
+// lookupFunction finds the function or macro named name as seen from
+// package code in env, and returns the package it was defined in.
+func lookupFunction(env *Env, name *symbol) (*Node, *Package, bool, error) {
+	if pname, local, internal, ok := splitQualified(name); ok {
+		p, ok := env.FindPackage(pname)
+		if !ok {
+			return nil, nil, false, fmt.Errorf("invalid package name: %v", pname)
+		}
+		if !internal && !p.exported(local) {
+			return nil, nil, false, fmt.Errorf("symbol not exported: %v", name)
+		}
+		fn, macro := p.lookup(local)
+		return fn, p, macro, nil
+	}
+
+	p := env.pkg
+	if fn, macro := p.lookup(name); fn != nil {
+		return fn, p, macro, nil
+	}
+	for _, u := range p.used() {
+		if !u.exported(name) {
+			continue
+		}
+		if fn, macro := u.lookup(name); fn != nil {
+			return fn, u, macro, nil
+		}
+	}
+	if p.name != DefaultPackage {
+		if u, ok := env.FindPackage(DefaultPackage); ok {
+			if fn, macro := u.lookup(name); fn != nil {
+				return fn, u, macro, nil
+			}
+		}
+	}
+	return nil, nil, false, nil
+}
+
+func packageName(node *Node) (string, error) {
+	name, ok := designatorName(node)
+	if !ok {
+		return "", fmt.Errorf("invalid package name: %v", node)
+	}
+	return name, nil
+}
+
+func packageOf(env *Env, node *Node) (*Package, error) {
+	name, err := packageName(node)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := env.FindPackage(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid package name: %v", name)
+	}
+	return p, nil
+}
+
+func usePackage(p, u *Package) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, x := range p.uses {
+		if x == u {
+			return
+		}
+	}
+	p.uses = append(p.uses[:len(p.uses):len(p.uses)], u)
+}
+
+func doDefpackage(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.car.t == NodeNil {
+		return nil, errors.New("invalid arguments for defpackage")
+	}
+	name, err := packageName(node.car)
+	if err != nil {
+		return nil, err
+	}
+	if name == "go" || strings.ContainsRune(name, ':') {
+		return nil, fmt.Errorf("invalid package name: %v", name)
+	}
+	root := env.root()
+	root.mu.Lock()
+	p, ok := root.pkgs[name]
+	if !ok {
+		p = newPackage(name)
+		root.pkgs[name] = p
+	}
+	root.mu.Unlock()
+
+	curr := node.cdr
+	for curr != nil && curr.car != nil {
+		opt := curr.car
+		if opt.t != NodeCell || opt.car == nil || opt.car.t != NodeIdent {
+			return nil, fmt.Errorf("invalid option for defpackage: %v", opt)
+		}
+		args := opt.cdr
+		switch opt.car.v.(*symbol).name {
+		case ":use":
+			for args != nil && args.car != nil {
+				u, err := packageOf(env, args.car)
+				if err != nil {
+					return nil, err
+				}
+				usePackage(p, u)
+				args = args.cdr
+			}
+		case ":export":
+			for args != nil && args.car != nil {
+				s, err := packageName(args.car)
+				if err != nil {
+					return nil, err
+				}
+				p.export(intern(s))
+				args = args.cdr
+			}
+		default:
+			return nil, fmt.Errorf("invalid option for defpackage: %v", opt)
+		}
+		curr = curr.cdr
+	}
+	return &Node{
+		t: NodeString,
+		v: p.name,
+	}, nil
+}
+
+func doInPackage(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.car.t == NodeNil {
+		return nil, errors.New("invalid arguments for in-package")
+	}
+	p, err := packageOf(env, node.car)
+	if err != nil {
+		return nil, err
+	}
+	env.pkg = p
+	return &Node{
+		t: NodeString,
+		v: p.name,
+	}, nil
+}
+
+func doUsePackage(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.car.t == NodeNil {
+		return nil, errors.New("invalid arguments for use-package")
+	}
+	u, err := packageOf(env, node.car)
+	if err != nil {
+		return nil, err
+	}
+	p := env.pkg
+	if node.cdr != nil && node.cdr.car != nil {
+		p, err = packageOf(env, node.cdr.car)
+		if err != nil {
+			return nil, err
+		}
+	}
+	usePackage(p, u)
+	return &Node{
+		t: NodeT,
+		v: true,
+	}, nil
+}
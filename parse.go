@@ -35,6 +35,8 @@ const (
 	NodeEnv
 	NodeError
 	NodeGoValue
+	NodeHash
+	NodeVector
 )
 
 type Node struct {
@@ -119,7 +121,7 @@ func (p *Parser) ParseParen(bq bool) (*Node, error) {
 			}
 		}
 
-		if child.t == NodeIdent && child.v.(string) == "." && !first {
+		if child.t == NodeIdent && child.v.(*symbol) == symDot && !first {
 			child, err = p.ParseAny(false)
 			if err != nil {
 				return nil, err
@@ -278,7 +280,7 @@ func (p *Parser) ParsePrimitive() (*Node, error) {
 	}
 	return &Node{
 		t: NodeIdent,
-		v: s,
+		v: intern(s),
 	}, nil
 }
 
@@ -361,6 +363,10 @@ func (n *Node) String() string {
 		} else {
 			fmt.Fprintf(&buf, "(defun %v %v)", n.v, n.cdr.car)
 		}
+	case NodeHash:
+		fmt.Fprint(&buf, n.v.(*HashTable))
+	case NodeVector:
+		buf.WriteString(vectorString(n.v.([]*Node)))
 	case NodeGoValue:
 		rv, ok := n.v.(reflect.Value)
 		if ok {
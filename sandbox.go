@@ -0,0 +1,225 @@
+package golisp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrForbidden is returned when a script uses a builtin or a Go package
+	// that the sandbox does not allow.
+	ErrForbidden = errors.New("forbidden")
+	// ErrStepLimit is returned when a script exceeds Sandbox.MaxSteps.
+	ErrStepLimit = errors.New("step limit exceeded")
+	// ErrDepthLimit is returned when a script exceeds Sandbox.MaxDepth.
+	ErrDepthLimit = errors.New("depth limit exceeded")
+	// ErrConsLimit is returned when a script exceeds Sandbox.MaxConses.
+	ErrConsLimit = errors.New("cons limit exceeded")
+	// ErrOutputLimit is returned when a script exceeds Sandbox.MaxOutput.
+	ErrOutputLimit = errors.New("output limit exceeded")
+	// ErrTimeLimit is returned when an evaluation exceeds Sandbox.Timeout.
+	ErrTimeLimit = errors.New("time limit exceeded")
+)
+
+// UnsafeBuiltins are the builtins that reach the host. A sandbox without
+// an explicit Builtins list allows every builtin but these.
+var UnsafeBuiltins = []string{"load", "getenv", "serve", "http-get", "http-post", "http-request"}
+
+// Sandbox restricts what scripts evaluated in an Env may do. Zero limits
+// mean no limit. Steps, conses and output are counted per evaluation,
+// including the goroutines it starts with go. Exceeding a limit fails the
+// evaluation that did, and later evaluations start from zero.
+type Sandbox struct {
+	// Packages lists the Go packages go:import may import. No package is
+	// allowed if it is empty.
+	Packages []string
+	// Builtins lists the builtins and special forms scripts may use. If it
+	// is nil, all builtins but UnsafeBuiltins are allowed.
+	Builtins []string
+
+	MaxSteps  int64         // forms evaluated
+	MaxDepth  int           // nested function calls
+	MaxConses int64         // cons cells allocated
+	MaxOutput int64         // bytes written by print functions
+	Timeout   time.Duration // wall-clock time of each evaluation and its goroutines
+}
+
+type sandbox struct {
+	Sandbox
+	packages map[string]bool
+}
+
+// usage counts the steps, conses and output of one evaluation against the
+// limits of a sandbox.
+type usage struct {
+	*sandbox
+	steps  int64
+	conses int64
+	output int64
+
+	mu  sync.Mutex
+	err error
+}
+
+// SetSandbox restricts e and every Env derived from it according to sb.
+// It should be called before anything is evaluated in e. Builtins
+// registered afterwards with Define are not restricted. The limits apply
+// to each EvalContext, and to each request served by HTTPHandler, on its
+// own.
+func (e *Env) SetSandbox(sb Sandbox) {
+	root := e.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	s := &sandbox{
+		Sandbox:  sb,
+		packages: make(map[string]bool),
+	}
+	for _, name := range sb.Packages {
+		s.packages[name] = true
+	}
+	allowed := make(map[*symbol]bool)
+	if sb.Builtins == nil {
+		for name := range root.ops {
+			allowed[name] = true
+		}
+		for _, name := range UnsafeBuiltins {
+			delete(allowed, intern(name))
+		}
+	} else {
+		for _, name := range sb.Builtins {
+			allowed[intern(name)] = true
+		}
+	}
+	for name, ft := range root.ops {
+		if !allowed[name] {
+			root.ops[name] = makeFn(ft.ft, forbidden(name.name))
+		}
+	}
+	root.sandbox = s
+}
+
+func forbidden(name string) Fn {
+	return func(env *Env, node *Node) (*Node, error) {
+		return nil, fmt.Errorf("%w: %v", ErrForbidden, name)
+	}
+}
+
+// fail records the first violation of the evaluation so that it is
+// reported even if the code that hit it drops the error.
+func (u *usage) fail(err error) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.err == nil {
+		u.err = err
+	}
+	return u.err
+}
+
+func (u *usage) failed() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.err
+}
+
+// step counts one evaluation step in e and checks the limits.
+func (e *Env) step() error {
+	if err := e.canceled(); err != nil {
+		return err
+	}
+	u := e.usage
+	if u == nil {
+		return nil
+	}
+	if err := u.failed(); err != nil {
+		return err
+	}
+	if u.MaxSteps > 0 && atomic.AddInt64(&u.steps, 1) > u.MaxSteps {
+		return u.fail(ErrStepLimit)
+	}
+	return nil
+}
+
+// enter checks that a function call from e may go one level deeper.
+func (e *Env) enter() error {
+	u := e.usage
+	if u == nil || u.MaxDepth <= 0 || e.depth < u.MaxDepth {
+		return nil
+	}
+	return u.fail(ErrDepthLimit)
+}
+
+// alloc counts n cons cells allocated in e.
+func (e *Env) alloc(n int64) error {
+	u := e.usage
+	if u == nil || u.MaxConses <= 0 {
+		return nil
+	}
+	if atomic.AddInt64(&u.conses, n) > u.MaxConses {
+		return u.fail(ErrConsLimit)
+	}
+	return nil
+}
+
+// allowPackage reports whether go:import may import the package name.
+func (e *Env) allowPackage(name string) error {
+	s := e.root().sandbox
+	if s == nil || s.packages[name] {
+		return nil
+	}
+	return fmt.Errorf("%w: package %v", ErrForbidden, name)
+}
+
+// limitScope makes scope, the frame of an evaluation with ctx, count the
+// evaluation against the sandbox of the interpreter, and applies its
+// wall-clock limit to ctx.
+func (e *Env) limitScope(scope *Env, ctx context.Context) context.CancelFunc {
+	scope.ctx = ctx
+	s := e.root().sandbox
+	if s == nil {
+		return func() {}
+	}
+	u := &usage{sandbox: s}
+	scope.usage = u
+	if s.MaxOutput > 0 {
+		scope.out = &limitWriter{w: scope.out, u: u}
+	}
+	if s.Timeout <= 0 {
+		return func() {}
+	}
+	var cancel context.CancelFunc
+	scope.ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+	return cancel
+}
+
+// sandboxError reports the violation behind err, if any, of the
+// evaluation in e. parent is the context the caller passed to EvalContext.
+func (e *Env) sandboxError(parent context.Context, err error) error {
+	u := e.usage
+	if u == nil {
+		return err
+	}
+	if errors.Is(err, ErrCanceled) && parent.Err() == nil {
+		return u.fail(ErrTimeLimit)
+	}
+	if err == nil {
+		return u.failed()
+	}
+	return err
+}
+
+type limitWriter struct {
+	w io.Writer
+	u *usage
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if atomic.AddInt64(&w.u.output, int64(len(p))) > w.u.MaxOutput {
+		return 0, w.u.fail(ErrOutputLimit)
+	}
+	return w.w.Write(p)
+}
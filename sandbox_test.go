@@ -0,0 +1,133 @@
+package golisp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSandbox(t *testing.T) {
+	tests := []struct {
+		sb   Sandbox
+		src  string
+		want error
+	}{
+		{Sandbox{}, `(getenv "HOME")`, ErrForbidden},
+		{Sandbox{}, `(load "testdata/01-add.lisp")`, ErrForbidden},
+		{Sandbox{}, `(go:import os)`, ErrForbidden},
+		{Sandbox{Builtins: []string{"+"}}, `(car '(1 2))`, ErrForbidden},
+		{Sandbox{MaxSteps: 100}, `(while t)`, ErrStepLimit},
+		{Sandbox{MaxDepth: 10}, `(defun f (n) (f (1+ n))) (f 0)`, ErrDepthLimit},
+		{Sandbox{MaxConses: 10}, `(list 1 2 3 4 5 6 7 8 9 10 11)`, ErrConsLimit},
+		{Sandbox{MaxOutput: 10}, `(while t (princ "hello"))`, ErrOutputLimit},
+		{Sandbox{Timeout: 50 * time.Millisecond}, `(while t)`, ErrTimeLimit},
+	}
+	for _, test := range tests {
+		env := NewEnv(nil)
+		env.SetOut(new(bytes.Buffer))
+		env.SetSandbox(test.sb)
+		_, err := env.EvalString(context.Background(), test.src)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: want %v but got %v", test.src, test.want, err)
+		}
+	}
+}
+
+func TestSandboxAllow(t *testing.T) {
+	env := NewEnv(nil)
+	var buf bytes.Buffer
+	env.SetOut(&buf)
+	env.SetSandbox(Sandbox{
+		Packages:  []string{"strings"},
+		MaxSteps:  1000,
+		MaxDepth:  10,
+		MaxConses: 100,
+		MaxOutput: 100,
+		Timeout:   time.Second,
+	})
+	ret, err := env.EvalString(context.Background(), `
+(setq s (go:import strings))
+(princ (car (.ToUpper s "hello")))
+(+ 1 2)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := ret.AsInt(); v != 3 {
+		t.Fatalf("want 3 but got %v", ret)
+	}
+	if got := buf.String(); got != "HELLO" {
+		t.Fatalf("want %q but got %q", "HELLO", got)
+	}
+}
+
+func TestSandboxPerEvaluation(t *testing.T) {
+	env := NewEnv(nil)
+	env.SetOut(new(bytes.Buffer))
+	env.SetSandbox(Sandbox{MaxSteps: 500, MaxDepth: 10, MaxOutput: 10})
+	ctx := context.Background()
+	if _, err := env.EvalString(ctx, `(defun f (n) (f (1+ n))) (f 0)`); !errors.Is(err, ErrDepthLimit) {
+		t.Fatalf("want ErrDepthLimit but got %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		ret, err := env.EvalString(ctx, `(setq i 0) (while (< i 10) (setq i (+ i 1))) (princ "hello") (+ 1 2)`)
+		if err != nil {
+			t.Fatalf("evaluation %d: %v", i, err)
+		}
+		if v, _ := ret.AsInt(); v != 3 {
+			t.Fatalf("want 3 but got %v", ret)
+		}
+	}
+	if _, err := env.EvalString(ctx, `(while t)`); !errors.Is(err, ErrStepLimit) {
+		t.Fatalf("want ErrStepLimit but got %v", err)
+	}
+}
+
+func TestSandboxCancel(t *testing.T) {
+	env := NewEnv(nil)
+	env.SetSandbox(Sandbox{Timeout: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := env.EvalString(ctx, `(while t)`)
+	if !errors.Is(err, ErrCanceled) || errors.Is(err, ErrTimeLimit) {
+		t.Fatalf("want ErrCanceled but got %v", err)
+	}
+}
+
+func TestSandboxRequire(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secret.lisp")
+	if err := os.WriteFile(file, []byte(`(setq leaked 1)`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []string{
+		fmt.Sprintf(`(require 'secret %q)`, file),
+		fmt.Sprintf(`(setq *load-path* (list %q)) (require 'secret)`, dir),
+	} {
+		env := NewEnv(nil)
+		env.SetSandbox(Sandbox{})
+		if _, err := env.EvalString(context.Background(), src); err == nil {
+			t.Errorf("%s: want error", src)
+		}
+		if _, ok := env.Lookup("leaked"); ok {
+			t.Errorf("%s: file on the host was loaded", src)
+		}
+	}
+
+	env := NewEnv(nil)
+	env.SetFS(fstest.MapFS{"mods/util.lisp": {Data: []byte(`(defun inc (x) (+ x 1))`)}})
+	env.SetSandbox(Sandbox{})
+	ret, err := env.EvalString(context.Background(), `(setq *load-path* (list "mods")) (require 'util) (require 'http/server) (inc 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := ret.AsInt(); v != 2 {
+		t.Fatalf("want 2 but got %v", ret)
+	}
+}
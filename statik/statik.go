@@ -1,13 +0,0 @@
-// Code generated by statik. DO NOT EDIT.
-
-// Package statik contains static assets.
-package statik
-
-import (
-	"github.com/rakyll/statik/fs"
-)
-
-func init() {
-	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\x84d\x83P\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x08\x00	\x00cdr.lispUT\x05\x00\x01\xb8-\x87^|\x931\x0e\x83P\x0cC\xf7\x9e\x82\xf1\xffcY\xbcv\xecP\xa9\x12\xc7\xafX~I\xe2\xb0 \xa4$\x0f\xc71\x83\xe7\xeb\xfb\xdev\xe9\xb3\x8dcnc?_\xce\xc71\xe7|\xac2\xa1L*\xb3\xa6q\xd3\x10\xcayZ\xf5\xe3\x8bq\xed\xa2vQ\xbaPV\xeaX\x94}\x0c\x0b\x95\xb5\x0c\x0bQ\xbb*+Y\xe4uQ\x9dr;v\x86\xfd\x89\xa1\xd9\xf8\x16D\x06\x8f\x0d\x99\x8e\x8c\xbf\x88%#\x7f\x18K\xa6\xe6\xad\xd7\x8c;y\xa7\x99\xe6\xf2\x8e\x8c\x9a\x0082r9h4\xa3\xfa\xc7\xb4\x9a\x11>a\x96\x9cC{\xe739\xbbw>c#\xdci\xee\x92|%\xff\x02\x00\x00\xff\xffPK\x07\x08\xb0\xd9\x8d\x97\xad\x00\x00\x00|\x04\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\x84d\x83P\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x08\x00	\x00nth.lispUT\x05\x00\x01\xb8-\x87^l\xccA\n\xc3@\x08\x85\xe1}N\xe1R\x8fUF\x87\x08v\x86\xaa\x81\x1c\xbf$\x0d\x06J\x96\xf2\x7f>d\xe9\xdb\x80\xae\x1e	\xb8\x13`{9\xecD\xcbUB\xda\x1c|'l|\xf6\x02\xb9\xaa\xff\xf7B\xa5\xfa\xdc<\xd7'v\xdb\xc2\xe3\x90\x06\x87\xe5\x99\xfa\x96\x00\xd4\xf3\x0c\xc9\x0f\xd8\xef\xc7\x88\xae1#Z\xbe\x01\x00\x00\xff\xffPK\x07\x08\x19\xf1	\xfdc\x00\x00\x00\xc9\x00\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\x84d\x83P\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00	\x00	\x00when.lispUT\x05\x00\x01\xb8-\x87^\xb2\xd6HIM\xcbML.\xcaW(\xcfH\xcdS\xd0H\xce\xcfK\xc9,\xc9\xcc\xcfSP+J-.QH\xcaO\xa9\xd4\xe4\xb2VPH\xd0\xc8LS\xd0AH\xeb8\x80\xa54\xb9\x00\x01\x00\x00\xff\xffPK\x07\x08\x8eJ\x13\xb6>\x00\x00\x00C\x00\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x84d\x83P\xb0\xd9\x8d\x97\xad\x00\x00\x00|\x04\x00\x00\x08\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb6\x81\x00\x00\x00\x00cdr.lispUT\x05\x00\x01\xb8-\x87^PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x84d\x83P\x19\xf1	\xfdc\x00\x00\x00\xc9\x00\x00\x00\x08\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb6\x81\xec\x00\x00\x00nth.lispUT\x05\x00\x01\xb8-\x87^PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x84d\x83P\x8eJ\x13\xb6>\x00\x00\x00C\x00\x00\x00	\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb6\x81\x8e\x01\x00\x00when.lispUT\x05\x00\x01\xb8-\x87^PK\x05\x06\x00\x00\x00\x00\x03\x00\x03\x00\xbe\x00\x00\x00\x0c\x02\x00\x00\x00\x00"
-	fs.Register(data)
-}
@@ -0,0 +1,369 @@
+package golisp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// symbol is a named object. Symbols made by intern are unique per name, so
+// they can be compared by pointer.
+type symbol struct {
+	name  string
+	plist *Node
+}
+
+var (
+	symbols   = make(map[string]*symbol)
+	symbolsMu sync.Mutex
+	// plistMu guards the property lists of all symbols.
+	plistMu sync.Mutex
+)
+
+var (
+	symQuote   = intern("quote")
+	symLambda  = intern("lambda")
+	symRest    = intern("&rest")
+	symDot     = intern(".")
+	symGet     = intern("get")
+	symGethash = intern("gethash")
+	symAref    = intern("aref")
+)
+
+// intern returns the symbol named name, creating it if it does not exist.
+func intern(name string) *symbol {
+	symbolsMu.Lock()
+	defer symbolsMu.Unlock()
+	s, ok := symbols[name]
+	if !ok {
+		s = &symbol{name: name}
+		symbols[name] = s
+	}
+	return s
+}
+
+// newSymbol returns a fresh uninterned symbol.
+func newSymbol(name string) *symbol {
+	return &symbol{name: name}
+}
+
+func (s *symbol) Name() string {
+	return s.name
+}
+
+func (s *symbol) String() string {
+	return s.name
+}
+
+// IsKeyword reports whether s is a keyword like :foo. Keywords evaluate to
+// themselves.
+func (s *symbol) IsKeyword() bool {
+	return strings.HasPrefix(s.name, ":") && len(s.name) > 1
+}
+
+// Get returns the value of indicator in the property list of s.
+func (s *symbol) Get(indicator *Node) (*Node, bool) {
+	plistMu.Lock()
+	defer plistMu.Unlock()
+	curr := s.plist
+	for curr != nil && curr.t == NodeCell && curr.cdr != nil {
+		if eq(curr.car, indicator) {
+			return curr.cdr.car, true
+		}
+		curr = curr.cdr.cdr
+	}
+	return nil, false
+}
+
+// Put sets the value of indicator in the property list of s.
+func (s *symbol) Put(indicator, value *Node) {
+	plistMu.Lock()
+	defer plistMu.Unlock()
+	curr := s.plist
+	for curr != nil && curr.t == NodeCell && curr.cdr != nil {
+		if eq(curr.car, indicator) {
+			curr.cdr.car = value
+			return
+		}
+		curr = curr.cdr.cdr
+	}
+	s.plist = &Node{
+		t:   NodeCell,
+		car: indicator,
+		cdr: &Node{
+			t:   NodeCell,
+			car: value,
+			cdr: s.plist,
+		},
+	}
+}
+
+func symbolOf(node *Node) (*symbol, bool) {
+	if node == nil || node.t != NodeIdent {
+		return nil, false
+	}
+	s, ok := node.v.(*symbol)
+	return s, ok
+}
+
+// designatorName returns the name denoted by a string, symbol or keyword.
+func designatorName(node *Node) (string, bool) {
+	if node == nil {
+		return "", false
+	}
+	switch node.t {
+	case NodeString:
+		return node.v.(string), true
+	case NodeIdent:
+		return strings.TrimPrefix(node.v.(*symbol).name, ":"), true
+	}
+	return "", false
+}
+
+// eq reports whether lhs and rhs are the same object. Symbols are compared
+// by identity, numbers, strings, nil and t by value.
+func eq(lhs, rhs *Node) bool {
+	if lhs == rhs {
+		return true
+	}
+	if lhs == nil || rhs == nil || lhs.t != rhs.t {
+		return false
+	}
+	switch lhs.t {
+	case NodeNil, NodeT:
+		return true
+	case NodeIdent, NodeInt, NodeDouble, NodeString:
+		return lhs.v == rhs.v
+	}
+	return false
+}
+
+func doEq(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for eq")
+	}
+	if eq(node.car, node.cdr.car) {
+		return &Node{
+			t: NodeT,
+			v: true,
+		}, nil
+	}
+	return &Node{
+		t: NodeNil,
+	}, nil
+}
+
+func doSymbolp(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for symbolp")
+	}
+	switch node.car.t {
+	case NodeIdent, NodeNil, NodeT:
+		return &Node{
+			t: NodeT,
+			v: true,
+		}, nil
+	}
+	return &Node{
+		t: NodeNil,
+	}, nil
+}
+
+func doKeywordp(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for keywordp")
+	}
+	if s, ok := symbolOf(node.car); ok && s.IsKeyword() {
+		return &Node{
+			t: NodeT,
+			v: true,
+		}, nil
+	}
+	return &Node{
+		t: NodeNil,
+	}, nil
+}
+
+func doSymbolName(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return nil, errors.New("invalid arguments for symbol-name")
+	}
+	var name string
+	switch node.car.t {
+	case NodeIdent:
+		name = node.car.v.(*symbol).name
+	case NodeNil:
+		name = "nil"
+	case NodeT:
+		name = "t"
+	default:
+		return nil, fmt.Errorf("argument should be symbol: %v", node.car)
+	}
+	return &Node{
+		t: NodeString,
+		v: name,
+	}, nil
+}
+
+func doSymbolPlist(env *Env, node *Node) (*Node, error) {
+	s, ok := symbolOf(node.car)
+	if !ok {
+		return nil, errors.New("invalid arguments for symbol-plist")
+	}
+	plistMu.Lock()
+	plist := s.plist
+	plistMu.Unlock()
+	if plist == nil {
+		return &Node{
+			t: NodeNil,
+		}, nil
+	}
+	return plist, nil
+}
+
+func doIntern(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.car.t != NodeString {
+		return nil, errors.New("invalid arguments for intern")
+	}
+	return &Node{
+		t: NodeIdent,
+		v: intern(node.car.v.(string)),
+	}, nil
+}
+
+func doMakeSymbol(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.car.t != NodeString {
+		return nil, errors.New("invalid arguments for make-symbol")
+	}
+	return &Node{
+		t: NodeIdent,
+		v: newSymbol(node.car.v.(string)),
+	}, nil
+}
+
+func doGet(env *Env, node *Node) (*Node, error) {
+	s, ok := symbolOf(node.car)
+	if !ok || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for get")
+	}
+	v, ok := s.Get(node.cdr.car)
+	if ok {
+		return v, nil
+	}
+	if node.cdr.cdr != nil && node.cdr.cdr.car != nil {
+		return node.cdr.cdr.car, nil
+	}
+	return &Node{
+		t: NodeNil,
+	}, nil
+}
+
+func doSetf(env *Env, node *Node) (*Node, error) {
+	var ret *Node
+	var err error
+	curr := node
+	for curr != nil && curr.car != nil && curr.car.t != NodeNil {
+		if curr.cdr == nil || curr.cdr.car == nil {
+			return nil, errors.New("invalid arguments for setf")
+		}
+		place := curr.car
+		ret, err = eval(env, curr.cdr.car)
+		if err != nil {
+			return nil, err
+		}
+		switch place.t {
+		case NodeIdent:
+			setVar(env, place.v.(*symbol), ret)
+		case NodeCell:
+			name, _ := symbolOf(place.car)
+			switch name {
+			case symGet:
+				if place.cdr == nil || place.cdr.cdr == nil {
+					return nil, errors.New("invalid arguments for setf")
+				}
+				obj, err := eval(env, place.cdr.car)
+				if err != nil {
+					return nil, err
+				}
+				s, ok := symbolOf(obj)
+				if !ok {
+					return nil, fmt.Errorf("argument should be symbol: %v", obj)
+				}
+				ind, err := eval(env, place.cdr.cdr.car)
+				if err != nil {
+					return nil, err
+				}
+				s.Put(ind, ret)
+			case symGethash:
+				if place.cdr == nil || place.cdr.cdr == nil {
+					return nil, errors.New("invalid arguments for setf")
+				}
+				key, err := eval(env, place.cdr.car)
+				if err != nil {
+					return nil, err
+				}
+				obj, err := eval(env, place.cdr.cdr.car)
+				if err != nil {
+					return nil, err
+				}
+				h, err := hashOf(obj, "setf")
+				if err != nil {
+					return nil, err
+				}
+				h.Set(key, ret)
+			case symAref:
+				if place.cdr == nil || place.cdr.cdr == nil {
+					return nil, errors.New("invalid arguments for setf")
+				}
+				obj, err := eval(env, place.cdr.car)
+				if err != nil {
+					return nil, err
+				}
+				items, err := vectorOf(obj, "setf")
+				if err != nil {
+					return nil, err
+				}
+				i, err := eval(env, place.cdr.cdr.car)
+				if err != nil {
+					return nil, err
+				}
+				n, err := vectorIndex(items, i, "setf")
+				if err != nil {
+					return nil, err
+				}
+				items[n] = ret
+			case symDot:
+				if err := setField(env, place, ret); err != nil {
+					return nil, err
+				}
+			case symGoIndex:
+				if place.cdr == nil || place.cdr.cdr == nil {
+					return nil, errors.New("invalid arguments for setf")
+				}
+				obj, err := eval(env, place.cdr.car)
+				if err != nil {
+					return nil, err
+				}
+				key, err := eval(env, place.cdr.cdr.car)
+				if err != nil {
+					return nil, err
+				}
+				if err := setIndex(env, obj, key, ret); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("invalid place for setf: %v", place)
+			}
+		default:
+			return nil, fmt.Errorf("invalid place for setf: %v", place)
+		}
+		curr = curr.cdr.cdr
+	}
+	if ret == nil {
+		ret = &Node{
+			t: NodeNil,
+		}
+	}
+	return ret, nil
+}
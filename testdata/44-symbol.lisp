@@ -0,0 +1,22 @@
+(print (eq 'foo 'foo))
+(print (eq 'foo 'bar))
+(print (eq (intern "foo") 'foo))
+(print (eq (make-symbol "foo") 'foo))
+(print (symbol-name 'foo))
+(print (symbolp 'foo))
+(print (symbolp "foo"))
+(print :key)
+(print (keywordp :key))
+(print (keywordp 'key))
+(print (type-of :key))
+(print (symbol-plist 'color))
+(setf (get 'color 'red) 1)
+(setf (get 'color 'green) 2)
+(print (get 'color 'red))
+(print (get 'color 'blue))
+(print (get 'color 'blue 3))
+(setf (get 'color 'red) 4)
+(print (get 'color 'red))
+(print (symbol-plist 'color))
+(setf x 10)
+(print x)
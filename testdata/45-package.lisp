@@ -0,0 +1,23 @@
+(defun helper () "user")
+(defpackage :alpha (:export :greet))
+(in-package :alpha)
+(defun helper () "alpha")
+(defun greet () (helper))
+(in-package :user)
+(defpackage :beta (:use :alpha) (:export :greet))
+(in-package :beta)
+(defun helper () "beta")
+(defun greet () (concatenate 'string (helper) "+" (alpha:greet)))
+(print (helper))
+(in-package :user)
+(print (helper))
+(print (alpha:greet))
+(print (beta:greet))
+(print (alpha::helper))
+(defpackage :gamma)
+(in-package :gamma)
+(use-package :alpha)
+(print (greet))
+(print (helper))
+(in-package :user)
+(print (nth '(1 2 3) 1))
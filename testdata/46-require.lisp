@@ -0,0 +1,7 @@
+(setq *load-path* (list "testdata/require"))
+(print (require 'greet))
+(print (require 'greet))
+(print (require 'names))
+(print (greet 'world))
+(print (require 'nth))
+(print (require "cdr"))
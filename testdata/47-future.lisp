@@ -0,0 +1,13 @@
+(defun square (n) (* n n))
+(setq f (future (square 7)))
+(print (await f))
+(print (pmap 'square '(1 2 3 4)))
+(print (pmap (lambda (x) (+ x 1)) '(10 20 30)))
+(setq ch (go:make-chan int))
+(print (with-task-group
+  (dotimes (i 3)
+    (go (go:chan-send ch i)))
+  (dotimes (i 3)
+    (go:chan-recv ch))
+  "done"))
+(print (await (future (with-task-group (future 1) (go (+ 1 1)) 3))))
@@ -0,0 +1,24 @@
+(setq a (go:make-chan string))
+(setq b (go:make-chan string))
+(go (go:chan-send b "from b"))
+(print (go:select
+  ((:recv a v) (list "a" v))
+  ((:recv b v ok) (list "b" v ok))))
+(print (go:select
+  ((:recv a v) "a")
+  (:default "nothing")))
+(print (go:select
+  ((:recv a v) "a")
+  ((:timeout "10ms") "timeout")))
+(go (go:chan-recv a))
+(print (go:select
+  ((:send a "sent") "send")
+  ((:timeout 1e9) "timeout")))
+(setq c (go:make-chan int))
+(go
+  (dotimes (i 3) (go:chan-send c i))
+  (go:chan-close c))
+(setq n 0)
+(do-chan (v c) (setq n (1+ n)))
+(print n)
+(print (go:chan-len c))
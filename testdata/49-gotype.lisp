@@ -0,0 +1,13 @@
+(print (go:type (slice (ptr net/http.Request))))
+(print (go:type (map string (slice int))))
+(print (go:type (array 3 byte)))
+(setq req-type (go:type net/http.Request))
+(print (go:type (chan (ptr req-type))))
+(setq ch (go:make-chan (ptr net/http.Request) 2))
+(print (go:chan-len ch))
+(go:chan-send ch (go:new net/http.Request))
+(print (go:chan-len ch))
+(print (go:make-slice int 3 10))
+(print (go:make-map string int))
+(print (go:convert float32 1))
+(print (go:convert (slice string) '("a" "b")))
@@ -0,0 +1,6 @@
+(setq c (go:new 'net/http.Cookie :Name "session" :Value "abc" :MaxAge 60 :HttpOnly t))
+(print (.String c))
+(print (go:zero int))
+(print (go:zero string))
+(setq strings (go:import strings))
+(print (.Repeat strings "ab" 3))
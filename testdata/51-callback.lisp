@@ -0,0 +1,5 @@
+(setq strings (go:import strings))
+(print (.Map strings (lambda (r) (+ r 1)) "HAL"))
+(defun comma-p (r) (= r 44))
+(print (.FieldsFunc strings "a,b,,c" 'comma-p))
+(print (.IndexFunc strings "hello world" (lambda (r) (= r 32))))
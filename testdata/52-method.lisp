@@ -0,0 +1,15 @@
+(setq fmt (go:import fmt))
+(print (.Sprintf fmt "%s-%d-%v" "a" 1 t))
+(print (.Sprint fmt))
+(setq strings (go:import strings))
+(setq b (go:new strings.Builder))
+(.WriteString b "hello")
+(.WriteString b ", world")
+(.WriteByte b 33)
+(print (.String b))
+(print (.Len b))
+(setq wg (go:new sync.WaitGroup))
+(.Add wg 1)
+(go (.Done wg))
+(.Wait wg)
+(print "waited")
@@ -0,0 +1,27 @@
+(setq h (make-hash-table))
+(setf (gethash "b" h) 2)
+(puthash :a 1 h)
+(setf (gethash 'c h) 3)
+(print (gethash "b" h))
+(print (gethash :a h))
+(print (gethash "missing" h))
+(print (gethash "missing" h 0))
+(print (hash-table-count h))
+(print (hash-table-keys h))
+(print h)
+(remhash "b" h)
+(print (hash-table-keys h))
+(maphash (lambda (k v) (print (list k v))) h)
+(print (hash-table-p h))
+(print (type-of h))
+(setq strconv (go:import strconv))
+(print (+ 1 (car (.Atoi strconv "2"))))
+(print (.Atoi strconv "2"))
+(print (handler-case (.Atoi strconv "x")
+  (error (e) (error-message e))))
+(print (handler-case (error "boom")
+  (error (e) (concatenate 'string "caught " (error-message e)))))
+(print (handler-case (+ 1 2)
+  (error (e) "unreachable")))
+(setq strings (go:import strings))
+(print (go:to-list (car (.Split strings "a,b,c" ","))))
@@ -0,0 +1,29 @@
+(setq strings (go:import strings))
+(setq xs (car (.Split strings "a,b,c,d" ",")))
+(print (go:len xs))
+(print (go:index xs 1))
+(go:set-index xs 1 "B")
+(setf (go:index xs 2) "C")
+(print (go:to-list xs))
+(print (go:to-list (go:slice xs 1 3)))
+(print (go:to-list (go:slice xs 2)))
+(print (go:slice "hello" 1 3))
+(go:range (x xs) (princ x))
+(print "")
+(go:range (i x xs) (princ i) (princ x))
+(print "")
+(setq m (go:make-map string int))
+(go:set-index m "b" 2)
+(setf (go:index m "a") 1)
+(print (go:index m "a"))
+(print (go:index m "z"))
+(print (go:len m))
+(go:range (k v m) (print (list k v)))
+(go:range (v m) (print v))
+(go:range (i r "aé日b") (print (list i r)))
+(print (car (.Index strings "aé日b" "日")))
+(setq c (go:new 'net/http.Cookie :Name "a"))
+(print (. c Name))
+(setf (. c Value) "b")
+(print (.String c))
+(let (((. c Name) "x")) (print (car (. c Name))))
@@ -0,0 +1,33 @@
+(setq io (go:import 'io))
+(setq ioutil (go:import 'io/ioutil))
+(setq fmt (go:import 'fmt))
+(setq sort (go:import 'sort))
+
+(setq done nil)
+(setq r (go:implement 'io.Reader
+  :Read (lambda (p)
+    (cond
+      (done (error (. io EOF)))
+      (t
+        (setq done t)
+        (go:range (i c "hello") (go:set-index p i c))
+        5)))))
+(print (go:convert string (car (.ReadAll ioutil r))))
+
+(print (.Sprint fmt (go:implement 'fmt.Stringer :string (lambda () "stringer"))))
+
+(setq s (go:make-slice int 8))
+(setq i 0)
+(setq vs '(3 1 4 1 5 9 2 6))
+(while (consp vs)
+  (go:set-index s i (car vs))
+  (setq vs (cdr vs))
+  (setq i (+ i 1)))
+(.Sort sort (go:implement 'sort.Interface
+  :len (lambda () (go:len s))
+  :less (lambda (i j) (< (go:index s i) (go:index s j)))
+  :swap (lambda (i j)
+    (let ((tmp (go:index s i)))
+      (go:set-index s i (go:index s j))
+      (go:set-index s j tmp)))))
+(print (go:to-list s))
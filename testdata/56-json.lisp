@@ -0,0 +1,21 @@
+(setq h (json-parse "{\"name\": \"日本語 \\u00e9\", \"count\": 3, \"ratio\": 0.5, \"ok\": true, \"none\": null, \"tags\": [\"a\", \"b\"]}"))
+(print (gethash "name" h))
+(print (gethash "tags" h))
+(print (json-stringify h))
+(print (json-stringify (json-parse "{\"big\": 123456789012345678901234567890, \"min\": -9223372036854775808, \"huge\": 1e400}")))
+(setq a (json-parse "{\"a\": 1, \"b\": {\"c\": [true, null]}}" :object-as :alist :keys :keyword))
+(print a)
+(print (json-stringify a :object-as :alist))
+(setq p (json-parse "{\"b\": 1, \"a\": 2}" :object-as :plist :keys :keyword))
+(print p)
+(print (json-stringify p :object-as :plist :sort-keys t))
+(print (json-stringify '(("x" . 1) ("y" . "<z>")) :pretty t))
+(print (json-stringify '(1 (2 3)) :pretty "\t"))
+(setq strings (go:import strings))
+(print (json-parse (car (.NewReader strings "[1, \"two\", 3.25]"))))
+(setq f (json-parse "{\"on\": false, \"off\": null, \"pi\": 3.14159265358979323846}"))
+(print (gethash "on" f))
+(print (json-stringify f))
+(print (json-stringify (json-parse "[false]" :false nil)))
+(print (json-stringify '(:red :green)))
+(print (json-stringify '(:red :green) :object-as :plist))
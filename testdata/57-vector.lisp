@@ -0,0 +1,14 @@
+(setq v (vector 1 "two" :three))
+(print v)
+(print (vectorp v))
+(print (vectorp '(1 2)))
+(print (type-of v))
+(print (length v))
+(print (aref v 1))
+(setf (aref v 0) 10)
+(print v)
+(print (coerce v 'list))
+(print (coerce '(1 2) 'vector))
+(print (coerce nil 'vector))
+(print (handler-case (aref v 3)
+  (error (e) (error-message e))))
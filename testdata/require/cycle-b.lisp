@@ -0,0 +1 @@
+(require 'cycle-a)
@@ -0,0 +1,4 @@
+(require 'names)
+(print "loading greet")
+(defun greet (x) (concatenate 'string "hello " (name x)))
+(provide 'greet)
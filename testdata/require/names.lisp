@@ -0,0 +1,2 @@
+(print "loading names")
+(defun name (x) (symbol-name x))
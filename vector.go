@@ -0,0 +1,112 @@
+package golisp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Vector returns a node holding a vector of nodes. Vectors have a fixed
+// length, and their elements are set in place with (setf (aref v i) x).
+func Vector(nodes ...*Node) *Node {
+	items := make([]*Node, len(nodes))
+	for i, n := range nodes {
+		if n == nil {
+			n = Nil()
+		}
+		items[i] = n
+	}
+	return &Node{
+		t: NodeVector,
+		v: items,
+	}
+}
+
+func vectorString(items []*Node) string {
+	var buf bytes.Buffer
+	buf.WriteString("#(")
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		fmt.Fprint(&buf, item)
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func vectorOf(node *Node, name string) ([]*Node, error) {
+	if node == nil || node.t != NodeVector {
+		return nil, fmt.Errorf("invalid arguments for %v", name)
+	}
+	return node.v.([]*Node), nil
+}
+
+// vectorIndex returns the index i of the vector items as an int.
+func vectorIndex(items []*Node, i *Node, name string) (int, error) {
+	if i.t != NodeInt {
+		return 0, fmt.Errorf("invalid index for %v: %v", name, i)
+	}
+	n := i.v.(int64)
+	if n < 0 || n >= int64(len(items)) {
+		return 0, fmt.Errorf("index %v out of range for %v", n, name)
+	}
+	return int(n), nil
+}
+
+func doVector(env *Env, node *Node) (*Node, error) {
+	if node.car == nil {
+		return Vector(), nil
+	}
+	return Vector(node.ToSlice()...), nil
+}
+
+func doVectorP(env *Env, node *Node) (*Node, error) {
+	return Bool(node.car != nil && node.car.t == NodeVector), nil
+}
+
+// doAref returns the element of a vector at an index.
+//
+//	(aref vector index)
+func doAref(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for aref")
+	}
+	items, err := vectorOf(node.car, "aref")
+	if err != nil {
+		return nil, err
+	}
+	i, err := vectorIndex(items, node.cdr.car, "aref")
+	if err != nil {
+		return nil, err
+	}
+	return items[i], nil
+}
+
+// doCoerce converts a sequence to a list or a vector.
+//
+//	(coerce sequence 'list)
+//	(coerce sequence 'vector)
+func doCoerce(env *Env, node *Node) (*Node, error) {
+	if node.car == nil || node.cdr == nil || node.cdr.car == nil {
+		return nil, errors.New("invalid arguments for coerce")
+	}
+	var items []*Node
+	switch node.car.t {
+	case NodeNil:
+	case NodeCell:
+		items = node.car.ToSlice()
+	case NodeVector:
+		items = node.car.v.([]*Node)
+	default:
+		return nil, fmt.Errorf("can not coerce %v", node.car)
+	}
+	typ, _ := designatorName(node.cdr.car)
+	switch typ {
+	case "list":
+		return List(items...), nil
+	case "vector":
+		return Vector(items...), nil
+	}
+	return nil, fmt.Errorf("invalid type for coerce: %v", node.cdr.car)
+}